@@ -0,0 +1,279 @@
+package ai
+
+import (
+	"context"
+	"math/bits"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+const (
+	MinLevel = 1
+	MaxLevel = 5
+)
+
+// depthByLevel maps difficulty levels to alpha-beta search depth in plies.
+var depthByLevel = [MaxLevel + 1]int{0, 1, 2, 4, 6, 8}
+
+// solveEmptiesByLevel is the number of empty squares at or below which a
+// level switches to the perfect endgame solver (0 disables it).
+var solveEmptiesByLevel = [MaxLevel + 1]int{0, 0, 0, 8, 12, 16}
+
+const (
+	EngineAlphaBeta = "alphabeta"
+	EngineMCTS      = "mcts"
+)
+
+func ValidEngine(engine string) bool {
+	return engine == EngineAlphaBeta || engine == EngineMCTS
+}
+
+// Settings selects the engine and strength of an AI opponent.
+type Settings struct {
+	Engine string
+	Level  int
+	// Playouts and TimeLimit override the level defaults for MCTS; zero keeps them.
+	Playouts  int
+	TimeLimit time.Duration
+}
+
+// Move picks a move for c with the configured engine.
+// It returns false when c has no legal move.
+func Move(ctx context.Context, b board.Board, c board.Color, s Settings) (board.Position, bool) {
+	if s.Engine != EngineMCTS {
+		return BestMove(ctx, b, c, s.Level)
+	}
+
+	cfg := MCTSConfigFor(s.Level)
+	if s.Playouts > 0 {
+		cfg.Playouts = s.Playouts
+	}
+	if s.TimeLimit > 0 {
+		cfg.TimeLimit = s.TimeLimit
+	}
+	res := MCTS(ctx, b, c, cfg)
+	if res.Move < 0 {
+		return board.Position{}, false
+	}
+	return board.PositionOf(res.Move), true
+}
+
+func ValidLevel(level int) bool {
+	return level >= MinLevel && level <= MaxLevel
+}
+
+// Depth returns the search depth used for a difficulty level.
+func Depth(level int) int {
+	if level < MinLevel {
+		level = MinLevel
+	}
+	if level > MaxLevel {
+		level = MaxLevel
+	}
+	return depthByLevel[level]
+}
+
+const (
+	winScore     = 1000000
+	mobilityUnit = 8
+)
+
+// squareWeights is the classic positional table: corners are valuable,
+// the squares next to them (C and X squares) are dangerous.
+var squareWeights = [64]int{
+	100, -20, 10, 5, 5, 10, -20, 100,
+	-20, -50, -2, -2, -2, -2, -50, -20,
+	10, -2, -1, -1, -1, -1, -2, 10,
+	5, -2, -1, -1, -1, -1, -2, 5,
+	5, -2, -1, -1, -1, -1, -2, 5,
+	10, -2, -1, -1, -1, -1, -2, 10,
+	-20, -50, -2, -2, -2, -2, -50, -20,
+	100, -20, 10, 5, 5, 10, -20, 100,
+}
+
+// Evaluate scores b from c's point of view using square weights and mobility.
+// Finished positions score as a win, loss or draw scaled by the disc margin.
+func Evaluate(b board.Board, c board.Color) int {
+	own, opp := b.Bits(c), b.Bits(c.Opponent())
+	ownMoves, oppMoves := b.Moves(c), b.Moves(c.Opponent())
+	if ownMoves == 0 && oppMoves == 0 {
+		return finalScore(own, opp)
+	}
+
+	score := 0
+	for m := own; m != 0; m &= m - 1 {
+		score += squareWeights[bits.TrailingZeros64(m)]
+	}
+	for m := opp; m != 0; m &= m - 1 {
+		score -= squareWeights[bits.TrailingZeros64(m)]
+	}
+	score += mobilityUnit * (bits.OnesCount64(ownMoves) - bits.OnesCount64(oppMoves))
+	return score
+}
+
+func finalScore(own, opp uint64) int {
+	diff := bits.OnesCount64(own) - bits.OnesCount64(opp)
+	switch {
+	case diff > 0:
+		return winScore + diff
+	case diff < 0:
+		return -winScore + diff
+	}
+	return 0
+}
+
+// Result is the outcome of a search. Move is -1 when c has no legal move.
+type Result struct {
+	Move  int
+	Score int
+	Depth int
+	Nodes uint64
+}
+
+// BestMove picks a move for c at the given difficulty level.
+// It returns false when c has no legal move.
+func BestMove(ctx context.Context, b board.Board, c board.Color, level int) (board.Position, bool) {
+	if ValidLevel(level) && b.Count(board.Empty) <= solveEmptiesByLevel[level] {
+		if sol, err := Solve(ctx, b, c); err == nil && len(sol.Line) > 0 && sol.Line[0] != Pass {
+			return board.PositionOf(sol.Line[0]), true
+		}
+	}
+	res := Search(ctx, b, c, Depth(level))
+	if res.Move < 0 {
+		return board.Position{}, false
+	}
+	return board.PositionOf(res.Move), true
+}
+
+// Search runs an iterative-deepening alpha-beta search up to maxDepth.
+// When ctx is cancelled the result of the last completed iteration is returned,
+// so a move is always available as long as one is legal.
+func Search(ctx context.Context, b board.Board, c board.Color, maxDepth int) Result {
+	moves := b.Moves(c)
+	if moves == 0 {
+		return Result{Move: -1, Score: Evaluate(b, c)}
+	}
+
+	s := &searcher{ctx: ctx}
+	best := Result{Move: bits.TrailingZeros64(moves), Score: Evaluate(b, c)}
+	order := orderMoves(moves, -1)
+	for depth := 1; depth <= maxDepth; depth++ {
+		move, score, ok := s.root(b, c, order, depth)
+		if !ok {
+			break
+		}
+		best = Result{Move: move, Score: score, Depth: depth}
+		order = orderMoves(moves, move)
+	}
+	best.Nodes = s.nodes
+	return best
+}
+
+type searcher struct {
+	ctx     context.Context
+	nodes   uint64
+	stopped bool
+	// lines, when non-nil, collects the principal variation found below
+	// each ply (triangular PV table).
+	lines [][]int
+}
+
+// checkEvery is how many nodes are searched between context checks.
+const checkEvery = 4096
+
+func (s *searcher) stop() bool {
+	if s.stopped {
+		return true
+	}
+	if s.nodes%checkEvery == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	return s.stopped
+}
+
+func (s *searcher) root(b board.Board, c board.Color, order []int, depth int) (int, int, bool) {
+	alpha, beta := -winScore*2, winScore*2
+	bestMove := order[0]
+	for _, sq := range order {
+		next := b
+		next.MakeMove(c, sq)
+		score := -s.negamax(next, c.Opponent(), depth-1, 1, -beta, -alpha, false)
+		if s.stopped {
+			return 0, 0, false
+		}
+		if score > alpha {
+			alpha = score
+			bestMove = sq
+		}
+	}
+	return bestMove, alpha, true
+}
+
+func (s *searcher) negamax(b board.Board, c board.Color, depth, ply, alpha, beta int, passed bool) int {
+	s.nodes++
+	if s.stop() {
+		return 0
+	}
+	if s.lines != nil {
+		s.lines[ply] = s.lines[ply][:0]
+	}
+	if depth <= 0 {
+		return Evaluate(b, c)
+	}
+
+	moves := b.Moves(c)
+	if moves == 0 {
+		if passed {
+			return finalScore(b.Bits(c), b.Bits(c.Opponent()))
+		}
+		score := -s.negamax(b, c.Opponent(), depth, ply+1, -beta, -alpha, true)
+		s.extend(ply, Pass)
+		return score
+	}
+
+	for _, sq := range orderMoves(moves, -1) {
+		next := b
+		next.MakeMove(c, sq)
+		score := -s.negamax(next, c.Opponent(), depth-1, ply+1, -beta, -alpha, false)
+		if score > alpha {
+			alpha = score
+			s.extend(ply, sq)
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+	return alpha
+}
+
+// extend sets the line at ply to sq followed by the line found one ply deeper.
+func (s *searcher) extend(ply, sq int) {
+	if s.lines == nil {
+		return
+	}
+	s.lines[ply] = append(append(s.lines[ply][:0], sq), s.lines[ply+1]...)
+}
+
+// orderMoves returns the squares of mask sorted by positional weight,
+// with first (if present) searched before everything else.
+func orderMoves(mask uint64, first int) []int {
+	moves := make([]int, 0, bits.OnesCount64(mask))
+	for m := mask; m != 0; m &= m - 1 {
+		moves = append(moves, bits.TrailingZeros64(m))
+	}
+	// insertion sort: move lists are short
+	for i := 1; i < len(moves); i++ {
+		for j := i; j > 0 && rank(moves[j], first) > rank(moves[j-1], first); j-- {
+			moves[j], moves[j-1] = moves[j-1], moves[j]
+		}
+	}
+	return moves
+}
+
+func rank(sq, first int) int {
+	if sq == first {
+		return winScore
+	}
+	return squareWeights[sq]
+}
@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+func TestValidLevel(t *testing.T) {
+	if ValidLevel(0) || ValidLevel(MaxLevel+1) {
+		t.Fatal("expected levels outside the range to be invalid")
+	}
+	for l := MinLevel; l <= MaxLevel; l++ {
+		if !ValidLevel(l) {
+			t.Fatalf("expected level %d to be valid", l)
+		}
+	}
+}
+
+func TestBestMove_IsLegal(t *testing.T) {
+	b := board.New()
+	for level := MinLevel; level <= MaxLevel; level++ {
+		pos, ok := BestMove(context.Background(), *b, board.Black, level)
+		if !ok {
+			t.Fatalf("level %d: expected a move", level)
+		}
+		if !b.IsValidMove(board.Black, pos.Col, pos.Row) {
+			t.Fatalf("level %d: expected legal move, got %v", level, pos)
+		}
+	}
+}
+
+func TestBestMove_TakesCorner(t *testing.T) {
+	// White can take a1 by bracketing b2 with c3
+	b := board.Board{}
+	for _, p := range []struct {
+		col, row int
+		c        board.Color
+	}{
+		{1, 1, board.Black}, {2, 2, board.White},
+		{3, 3, board.Black}, {4, 4, board.White},
+		{4, 3, board.Black}, {3, 4, board.Black},
+	} {
+		b = place(b, p.col, p.row, p.c)
+	}
+	pos, ok := BestMove(context.Background(), b, board.White, 2)
+	if !ok {
+		t.Fatal("expected a move")
+	}
+	if pos != (board.Position{Col: 0, Row: 0}) {
+		t.Fatalf("expected corner a1, got %v", pos)
+	}
+}
+
+func TestBestMove_NoLegalMove(t *testing.T) {
+	b := board.Board{}
+	b = place(b, 0, 0, board.Black)
+	if _, ok := BestMove(context.Background(), b, board.White, 3); ok {
+		t.Fatal("expected no move for white")
+	}
+}
+
+func TestSearch_CancelledContextStillReturnsMove(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := board.New()
+	res := Search(ctx, *b, board.Black, 20)
+	if res.Move < 0 {
+		t.Fatal("expected a fallback move")
+	}
+	pos := board.PositionOf(res.Move)
+	if !b.IsValidMove(board.Black, pos.Col, pos.Row) {
+		t.Fatalf("expected legal fallback move, got %v", pos)
+	}
+}
+
+func TestEvaluate_FinishedGame(t *testing.T) {
+	b := board.Board{}
+	b = place(b, 0, 0, board.Black)
+	b = place(b, 7, 7, board.Black)
+	if Evaluate(b, board.Black) <= winScore/2 {
+		t.Fatal("expected a won position for black")
+	}
+	if Evaluate(b, board.White) >= -winScore/2 {
+		t.Fatal("expected a lost position for white")
+	}
+}
+
+func TestDepth_Clamped(t *testing.T) {
+	if Depth(0) != Depth(MinLevel) || Depth(MaxLevel+3) != Depth(MaxLevel) {
+		t.Fatal("expected out-of-range levels to be clamped")
+	}
+	if Depth(MaxLevel) <= Depth(MinLevel) {
+		t.Fatal("expected higher levels to search deeper")
+	}
+}
+
+// place builds test positions square by square without rule checks.
+func place(b board.Board, col, row int, c board.Color) board.Board {
+	b.Set(col, row, c)
+	return b
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"math/bits"
+	"sort"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// MoveScore is the evaluation of one legal move.
+type MoveScore struct {
+	Move int
+	// Score is from the mover's point of view: Evaluate units for heuristic
+	// analyses, the final disc differential for exact ones.
+	Score int
+	// Line is the principal variation starting with Move; Pass entries mark
+	// forced passes.
+	Line []int
+}
+
+// Analysis scores every legal move of a position, best first.
+type Analysis struct {
+	Moves []MoveScore
+	// Depth is the deepest fully completed search; 0 means only the static
+	// evaluation was available.
+	Depth int
+	// Exact is set when the scores come from the endgame solver.
+	Exact bool
+	Nodes uint64
+}
+
+// Analyze scores each legal move of c with an iterative-deepening alpha-beta
+// search up to maxDepth. Unlike Search every root move gets a full window, so
+// the scores are comparable. When ctx is cancelled the last completed depth is
+// returned.
+func Analyze(ctx context.Context, b board.Board, c board.Color, maxDepth int) Analysis {
+	moves := b.Moves(c)
+	if moves == 0 {
+		return Analysis{Moves: []MoveScore{}}
+	}
+
+	res := Analysis{}
+	for _, sq := range orderMoves(moves, -1) {
+		next := b
+		next.MakeMove(c, sq)
+		res.Moves = append(res.Moves, MoveScore{Move: sq, Score: -Evaluate(next, c.Opponent()), Line: []int{sq}})
+	}
+	sortMoveScores(res.Moves)
+
+	s := &searcher{ctx: ctx, lines: make([][]int, 2*maxDepth+4)}
+	for depth := 1; depth <= maxDepth && ctx.Err() == nil; depth++ {
+		scored := make([]MoveScore, 0, len(res.Moves))
+		for _, m := range res.Moves {
+			next := b
+			next.MakeMove(c, m.Move)
+			score := -s.negamax(next, c.Opponent(), depth-1, 1, -winScore*2, winScore*2, false)
+			if s.stopped {
+				break
+			}
+			line := append([]int{m.Move}, s.lines[1]...)
+			scored = append(scored, MoveScore{Move: m.Move, Score: score, Line: line})
+		}
+		if s.stopped {
+			break
+		}
+		sortMoveScores(scored)
+		res.Moves, res.Depth = scored, depth
+	}
+	res.Nodes = s.nodes
+	return res
+}
+
+// AnalyzeExact solves every legal move of c to the end of the game.
+func AnalyzeExact(ctx context.Context, b board.Board, c board.Color) (Analysis, error) {
+	if b.Count(board.Empty) > MaxSolveEmpties {
+		return Analysis{}, ErrTooManyEmpties
+	}
+
+	res := Analysis{Moves: []MoveScore{}, Depth: b.Count(board.Empty), Exact: true}
+	for m := b.Moves(c); m != 0; m &= m - 1 {
+		sq := bits.TrailingZeros64(m)
+		next := b
+		next.MakeMove(c, sq)
+		sol, err := Solve(ctx, next, c.Opponent())
+		if err != nil {
+			return Analysis{}, err
+		}
+		res.Moves = append(res.Moves, MoveScore{Move: sq, Score: -sol.Score, Line: append([]int{sq}, sol.Line...)})
+		res.Nodes += sol.Nodes
+	}
+	sortMoveScores(res.Moves)
+	return res, nil
+}
+
+func sortMoveScores(ms []MoveScore) {
+	sort.SliceStable(ms, func(i, j int) bool { return ms[i].Score > ms[j].Score })
+}
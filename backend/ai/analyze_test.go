@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// validLine replays line from b with c to move and reports whether every
+// entry, passes included, is legal.
+func validLine(b board.Board, c board.Color, line []int) bool {
+	for _, sq := range line {
+		if sq == Pass {
+			if b.Moves(c) != 0 {
+				return false
+			}
+		} else if b.Moves(c)&(uint64(1)<<sq) == 0 {
+			return false
+		} else {
+			b.MakeMove(c, sq)
+		}
+		c = c.Opponent()
+	}
+	return true
+}
+
+func TestAnalyze_RanksEveryMove(t *testing.T) {
+	b := board.New()
+	res := Analyze(context.Background(), *b, board.Black, 4)
+	if len(res.Moves) != 4 {
+		t.Fatalf("expected 4 moves, got %d", len(res.Moves))
+	}
+	if res.Depth != 4 {
+		t.Fatalf("expected depth 4, got %d", res.Depth)
+	}
+	for i, m := range res.Moves {
+		if i > 0 && m.Score > res.Moves[i-1].Score {
+			t.Fatalf("expected moves sorted by score, got %+v", res.Moves)
+		}
+		if len(m.Line) != 4 || m.Line[0] != m.Move {
+			t.Fatalf("expected a 4-ply line starting with the move, got %v", m.Line)
+		}
+		if !validLine(*b, board.Black, m.Line) {
+			t.Fatalf("expected a legal line, got %v", m.Line)
+		}
+	}
+}
+
+func TestAnalyze_FindsWinningMove(t *testing.T) {
+	b := *board.New()
+	c := board.Black
+	for _, m := range [][2]int{{3, 2}, {2, 2}, {1, 2}, {3, 1}, {4, 0}, {3, 5}, {3, 6}, {4, 2}} {
+		b.MakeMove(c, board.Square(m[0], m[1]))
+		c = c.Opponent()
+	}
+
+	res := Analyze(context.Background(), b, board.Black, 3)
+	if res.Moves[0].Move != board.Square(5, 3) {
+		t.Fatalf("expected f4 first, got %v", board.PositionOf(res.Moves[0].Move))
+	}
+	if res.Moves[0].Score < winScore {
+		t.Fatalf("expected a winning score, got %d", res.Moves[0].Score)
+	}
+}
+
+func TestAnalyze_CancelledReturnsStaticScores(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := board.New()
+
+	res := Analyze(ctx, *b, board.Black, 10)
+	if res.Depth != 0 || len(res.Moves) != 4 {
+		t.Fatalf("expected 4 depth-0 moves, got depth %d with %d moves", res.Depth, len(res.Moves))
+	}
+}
+
+func TestAnalyzeExact_MatchesSolve(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		b, c := randomPosition(seed, 10)
+		if b.Moves(c) == 0 {
+			continue
+		}
+		sol, err := Solve(context.Background(), b, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := AnalyzeExact(context.Background(), b, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Exact || res.Moves[0].Score != sol.Score {
+			t.Fatalf("seed %d: expected best score %d, got %d", seed, sol.Score, res.Moves[0].Score)
+		}
+		for _, m := range res.Moves {
+			if !validLine(b, c, m.Line) {
+				t.Fatalf("seed %d: expected a legal line, got %v", seed, m.Line)
+			}
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// MaxSolveEmpties is the largest number of empty squares Solve accepts.
+const MaxSolveEmpties = 20
+
+var (
+	ErrTooManyEmpties = errors.New("position has too many empty squares to solve")
+	ErrSolveCancelled = errors.New("endgame solve was cancelled")
+)
+
+// Pass marks a forced pass in a Solution line.
+const Pass = -1
+
+// Solution is the exact result of an endgame under perfect play.
+type Solution struct {
+	// Score is the final disc differential (own minus opponent) from the
+	// point of view of the side to move.
+	Score int
+	// Line is the best line in play order; Pass entries mark forced passes.
+	Line  []int
+	Nodes uint64
+}
+
+// Solve computes the exact outcome of b with c to move.
+func Solve(ctx context.Context, b board.Board, c board.Color) (Solution, error) {
+	if b.Count(board.Empty) > MaxSolveEmpties {
+		return Solution{}, ErrTooManyEmpties
+	}
+
+	s := &solver{ctx: ctx, tt: newTable(ttBits)}
+	score := s.solve(b, c, -64, 64, false)
+	if s.stopped {
+		return Solution{}, ErrSolveCancelled
+	}
+	line := s.line(b, c, score)
+	if s.stopped {
+		return Solution{}, ErrSolveCancelled
+	}
+	return Solution{Score: score, Line: line, Nodes: s.nodes}, nil
+}
+
+const (
+	ttBits = 18
+	// ttMinEmpties skips the table near the leaves where probing costs more
+	// than re-searching.
+	ttMinEmpties = 7
+	// fastestFirstMinEmpties enables mobility-based ordering; below it parity
+	// ordering alone is cheaper.
+	fastestFirstMinEmpties = 7
+)
+
+type ttEntry struct {
+	own, opp     uint64
+	lower, upper int8
+	move         int8
+}
+
+type table struct {
+	entries []ttEntry
+	shift   uint
+}
+
+func newTable(n uint) *table {
+	return &table{entries: make([]ttEntry, 1<<n), shift: 64 - n}
+}
+
+func (t *table) slot(own, opp uint64) *ttEntry {
+	h := own*0x9e3779b97f4a7c15 ^ opp*0xc2b2ae3d27d4eb4f
+	return &t.entries[h>>t.shift]
+}
+
+type solver struct {
+	ctx     context.Context
+	tt      *table
+	nodes   uint64
+	stopped bool
+}
+
+func (s *solver) stop() bool {
+	if s.stopped {
+		return true
+	}
+	if s.nodes%checkEvery == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	return s.stopped
+}
+
+func discDiff(b board.Board, c board.Color) int {
+	return b.Count(c) - b.Count(c.Opponent())
+}
+
+func (s *solver) solve(b board.Board, c board.Color, alpha, beta int, passed bool) int {
+	s.nodes++
+	if s.stop() {
+		return 0
+	}
+	empty := b.Bits(board.Empty)
+	if empty == 0 {
+		return discDiff(b, c)
+	}
+	moves := b.Moves(c)
+	if moves == 0 {
+		if passed {
+			return discDiff(b, c)
+		}
+		return -s.solve(b, c.Opponent(), -beta, -alpha, true)
+	}
+
+	empties := bits.OnesCount64(empty)
+	ttMove := -1
+	var entry *ttEntry
+	own, opp := b.Bits(c), b.Bits(c.Opponent())
+	if empties >= ttMinEmpties {
+		entry = s.tt.slot(own, opp)
+		if entry.own == own && entry.opp == opp {
+			lower, upper := int(entry.lower), int(entry.upper)
+			if lower >= beta {
+				return lower
+			}
+			if upper <= alpha {
+				return upper
+			}
+			if lower > alpha {
+				alpha = lower
+			}
+			if upper < beta {
+				beta = upper
+			}
+			ttMove = int(entry.move)
+		}
+	}
+
+	origAlpha := alpha
+	best, bestMove := -65, -1
+	var buf [32]int
+	for i, sq := range s.order(buf[:0], b, c, moves, empty, ttMove) {
+		next := b
+		next.MakeMove(c, sq)
+		var v int
+		if i == 0 {
+			v = -s.solve(next, c.Opponent(), -beta, -alpha, false)
+		} else {
+			// principal variation search: prove the later moves are no better
+			// with a null window and re-search only when one is
+			v = -s.solve(next, c.Opponent(), -alpha-1, -alpha, false)
+			if v > alpha && v < beta {
+				v = -s.solve(next, c.Opponent(), -beta, -v, false)
+			}
+		}
+		if v > best {
+			best, bestMove = v, sq
+			if v > alpha {
+				alpha = v
+				if alpha >= beta {
+					break
+				}
+			}
+		}
+	}
+
+	if entry != nil && !s.stopped {
+		e := ttEntry{own: own, opp: opp, lower: -64, upper: 64, move: int8(bestMove)}
+		switch {
+		case best <= origAlpha:
+			e.upper = int8(best)
+		case best >= beta:
+			e.lower = int8(best)
+		default:
+			e.lower, e.upper = int8(best), int8(best)
+		}
+		*entry = e
+	}
+	return best
+}
+
+// quadrants are the four 4x4 regions used for parity ordering.
+var quadrants = [4]uint64{
+	0x000000000f0f0f0f,
+	0x00000000f0f0f0f0,
+	0x0f0f0f0f00000000,
+	0xf0f0f0f000000000,
+}
+
+// order appends moves to dst sorted: the table move first, then moves into
+// regions with an odd number of empties (parity), then by fewest opponent replies.
+func (s *solver) order(dst []int, b board.Board, c board.Color, moves, empty uint64, ttMove int) []int {
+	var odd uint64
+	for _, q := range quadrants {
+		if bits.OnesCount64(empty&q)%2 == 1 {
+			odd |= q
+		}
+	}
+
+	var keys [32]int
+	fastest := bits.OnesCount64(empty) >= fastestFirstMinEmpties
+	for m := moves; m != 0; m &= m - 1 {
+		sq := bits.TrailingZeros64(m)
+		key := squareWeights[sq]
+		if sq == ttMove {
+			key += 1 << 20
+		}
+		if fastest {
+			// far from the end, opponent mobility dominates and parity is a tie-break
+			next := b
+			next.MakeMove(c, sq)
+			key -= bits.OnesCount64(next.Moves(c.Opponent())) * 1024
+			if odd&(uint64(1)<<sq) != 0 {
+				key += 64
+			}
+		} else if odd&(uint64(1)<<sq) != 0 {
+			key += 1024
+		}
+
+		i := len(dst)
+		dst = append(dst, sq)
+		for ; i > 0 && key > keys[i-1]; i-- {
+			dst[i], keys[i] = dst[i-1], keys[i-1]
+		}
+		dst[i], keys[i] = sq, key
+	}
+	return dst
+}
+
+// line rebuilds a principal variation by picking, at each ply, a move whose
+// exact value equals the known score.
+func (s *solver) line(b board.Board, c board.Color, score int) []int {
+	var line []int
+	for {
+		moves := b.Moves(c)
+		if moves == 0 {
+			if b.Moves(c.Opponent()) == 0 {
+				return line
+			}
+			line = append(line, Pass)
+			c = c.Opponent()
+			score = -score
+			continue
+		}
+
+		chosen := -1
+		var buf [32]int
+		for _, sq := range s.order(buf[:0], b, c, moves, b.Bits(board.Empty), -1) {
+			next := b
+			next.MakeMove(c, sq)
+			if -s.solve(next, c.Opponent(), -score-1, -score+1, false) == score {
+				chosen = sq
+				break
+			}
+			if s.stopped {
+				return nil
+			}
+		}
+		if chosen < 0 {
+			return line
+		}
+		line = append(line, chosen)
+		b.MakeMove(c, chosen)
+		c = c.Opponent()
+		score = -score
+	}
+}
@@ -0,0 +1,236 @@
+package ai
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// MCTSConfig bounds a Monte Carlo tree search. The search stops at whichever
+// of Playouts, TimeLimit or context cancellation comes first.
+type MCTSConfig struct {
+	Playouts  int
+	TimeLimit time.Duration
+	// Workers is the number of goroutines sharing the tree;
+	// zero means GOMAXPROCS.
+	Workers int
+	// Exploration is the UCT constant; zero means sqrt(2).
+	Exploration float64
+}
+
+var (
+	playoutsByLevel  = [MaxLevel + 1]int{0, 300, 1500, 6000, 25000, 100000}
+	timeLimitByLevel = [MaxLevel + 1]time.Duration{0, time.Second, time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second}
+)
+
+// MCTSConfigFor returns the default budget for a difficulty level.
+func MCTSConfigFor(level int) MCTSConfig {
+	if level < MinLevel {
+		level = MinLevel
+	}
+	if level > MaxLevel {
+		level = MaxLevel
+	}
+	return MCTSConfig{Playouts: playoutsByLevel[level], TimeLimit: timeLimitByLevel[level]}
+}
+
+type mctsNode struct {
+	parent   *mctsNode
+	move     int         // square played to reach this node, or Pass
+	mover    board.Color // side that played move
+	board    board.Board
+	turn     board.Color // side to move; Empty when the game is over
+	untried  []int
+	children []*mctsNode
+	visits   float64
+	wins     float64 // from mover's point of view; draws count half
+}
+
+func newMCTSNode(parent *mctsNode, move int, mover board.Color, b board.Board, turn board.Color) *mctsNode {
+	n := &mctsNode{parent: parent, move: move, mover: mover, board: b, turn: turn}
+	if turn == board.Empty {
+		return n
+	}
+	moves := b.Moves(turn)
+	if moves == 0 {
+		n.untried = []int{Pass}
+		return n
+	}
+	for m := moves; m != 0; m &= m - 1 {
+		n.untried = append(n.untried, bits.TrailingZeros64(m))
+	}
+	return n
+}
+
+// play returns the board and side to move after mover plays move (or passes).
+func play(b board.Board, mover board.Color, move int) (board.Board, board.Color) {
+	if move != Pass {
+		b.MakeMove(mover, move)
+	}
+	next := mover.Opponent()
+	if b.Moves(next) != 0 {
+		return b, next
+	}
+	if b.Moves(mover) != 0 {
+		return b, next // next must pass
+	}
+	return b, board.Empty
+}
+
+func (n *mctsNode) uctChild(exploration float64) *mctsNode {
+	logN := math.Log(n.visits)
+	var best *mctsNode
+	bestScore := math.Inf(-1)
+	for _, c := range n.children {
+		score := c.wins/c.visits + exploration*math.Sqrt(logN/c.visits)
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+// MCTSResult is the outcome of MCTS. Move is -1 when c has no legal move.
+type MCTSResult struct {
+	Move     int
+	WinRate  float64
+	Playouts int
+}
+
+// MCTS runs a UCT search from b with c to move. Workers share one tree
+// guarded by a mutex; playouts themselves run outside the lock, and
+// visits are counted on descent so parallel workers spread over the tree.
+func MCTS(ctx context.Context, b board.Board, c board.Color, cfg MCTSConfig) MCTSResult {
+	moves := b.Moves(c)
+	if moves == 0 {
+		return MCTSResult{Move: -1}
+	}
+	if bits.OnesCount64(moves) == 1 {
+		return MCTSResult{Move: bits.TrailingZeros64(moves)}
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	exploration := cfg.Exploration
+	if exploration == 0 {
+		exploration = math.Sqrt2
+	}
+	if cfg.TimeLimit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.TimeLimit)
+		defer cancel()
+	}
+
+	root := newMCTSNode(nil, Pass, c.Opponent(), b, c)
+	var (
+		mu       sync.Mutex
+		playouts int
+		wg       sync.WaitGroup
+	)
+	seed := time.Now().UnixNano()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				mu.Lock()
+				if cfg.Playouts > 0 && playouts >= cfg.Playouts {
+					mu.Unlock()
+					return
+				}
+				playouts++
+
+				n := root
+				for len(n.untried) == 0 && len(n.children) > 0 {
+					n = n.uctChild(exploration)
+				}
+				if len(n.untried) > 0 {
+					i := rng.Intn(len(n.untried))
+					move := n.untried[i]
+					n.untried[i] = n.untried[len(n.untried)-1]
+					n.untried = n.untried[:len(n.untried)-1]
+					nb, turn := play(n.board, n.turn, move)
+					child := newMCTSNode(n, move, n.turn, nb, turn)
+					n.children = append(n.children, child)
+					n = child
+				}
+				for x := n; x != nil; x = x.parent {
+					x.visits++
+				}
+				leafBoard, leafTurn := n.board, n.turn
+				mu.Unlock()
+
+				winner := playout(leafBoard, leafTurn, rng)
+
+				mu.Lock()
+				for x := n; x != nil; x = x.parent {
+					switch winner {
+					case x.mover:
+						x.wins++
+					case board.Empty:
+						x.wins += 0.5
+					}
+				}
+				mu.Unlock()
+			}
+		}(rand.New(rand.NewSource(seed + int64(i))))
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	res := MCTSResult{Move: -1, Playouts: playouts}
+	var bestVisits float64 = -1
+	for _, ch := range root.children {
+		if ch.visits > bestVisits {
+			bestVisits = ch.visits
+			res.Move = ch.move
+			res.WinRate = ch.wins / ch.visits
+		}
+	}
+	if res.Move < 0 {
+		// cancelled before a single expansion: fall back to any legal move
+		res.Move = bits.TrailingZeros64(moves)
+	}
+	return res
+}
+
+// playout plays random moves to the end and returns the winner (Empty for a draw).
+func playout(b board.Board, turn board.Color, rng *rand.Rand) board.Color {
+	passed := false
+	for turn != board.Empty {
+		moves := b.Moves(turn)
+		if moves == 0 {
+			if passed {
+				break
+			}
+			passed = true
+			turn = turn.Opponent()
+			continue
+		}
+		passed = false
+		k := rng.Intn(bits.OnesCount64(moves))
+		for ; k > 0; k-- {
+			moves &= moves - 1
+		}
+		b.MakeMove(turn, bits.TrailingZeros64(moves))
+		turn = turn.Opponent()
+	}
+
+	black, white := b.Count(board.Black), b.Count(board.White)
+	switch {
+	case black > white:
+		return board.Black
+	case white > black:
+		return board.White
+	}
+	return board.Empty
+}
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+func TestMCTS_IsLegal(t *testing.T) {
+	b := board.New()
+	res := MCTS(context.Background(), *b, board.Black, MCTSConfig{Playouts: 500, Workers: 4})
+	if res.Move < 0 {
+		t.Fatal("expected a move")
+	}
+	pos := board.PositionOf(res.Move)
+	if !b.IsValidMove(board.Black, pos.Col, pos.Row) {
+		t.Fatalf("expected legal move, got %v", pos)
+	}
+	if res.Playouts != 500 {
+		t.Fatalf("expected 500 playouts, got %d", res.Playouts)
+	}
+}
+
+func TestMCTS_FindsWinningMove(t *testing.T) {
+	// Nine-move game: black f4 wipes out white and ends the game
+	b := *board.New()
+	c := board.Black
+	for _, m := range [][2]int{{3, 2}, {2, 2}, {1, 2}, {3, 1}, {4, 0}, {3, 5}, {3, 6}, {4, 2}} {
+		if err := b.Place(c, m[0], m[1]); err != nil {
+			t.Fatalf("expected legal move %v, got %v", m, err)
+		}
+		c = c.Opponent()
+	}
+
+	res := MCTS(context.Background(), b, board.Black, MCTSConfig{Playouts: 3000, Workers: 2})
+	if res.Move != board.Square(5, 3) {
+		t.Fatalf("expected f4, got %v", board.PositionOf(res.Move))
+	}
+}
+
+func TestMCTS_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := board.New()
+
+	began := time.Now()
+	res := MCTS(ctx, *b, board.Black, MCTSConfig{TimeLimit: time.Minute})
+	if time.Since(began) > time.Second {
+		t.Fatal("expected cancelled search to return immediately")
+	}
+	pos := board.PositionOf(res.Move)
+	if !b.IsValidMove(board.Black, pos.Col, pos.Row) {
+		t.Fatalf("expected legal fallback move, got %v", pos)
+	}
+}
+
+func TestMCTS_TimeLimit(t *testing.T) {
+	b := board.New()
+	began := time.Now()
+	MCTS(context.Background(), *b, board.Black, MCTSConfig{TimeLimit: 50 * time.Millisecond})
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Fatalf("expected search to respect the time limit, took %v", elapsed)
+	}
+}
+
+func TestMove_SelectsEngine(t *testing.T) {
+	b := board.New()
+	for _, engine := range []string{EngineAlphaBeta, EngineMCTS} {
+		pos, ok := Move(context.Background(), *b, board.Black, Settings{Engine: engine, Level: 1, Playouts: 200})
+		if !ok || !b.IsValidMove(board.Black, pos.Col, pos.Row) {
+			t.Fatalf("%s: expected a legal move, got %v", engine, pos)
+		}
+	}
+}
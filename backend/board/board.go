@@ -0,0 +1,301 @@
+package board
+
+import (
+	"errors"
+	"math/bits"
+)
+
+const Size = 8
+
+var (
+	ErrOutOfRange = errors.New("col and row must be between 0 and 7")
+	ErrOccupied   = errors.New("square is already occupied")
+	ErrNoFlips    = errors.New("move does not flip any stones")
+)
+
+type Color int
+
+const (
+	Empty Color = iota
+	Black
+	White
+)
+
+// ParseColor converts the API color string ("black" / "white") into a Color.
+func ParseColor(s string) (Color, bool) {
+	switch s {
+	case "black":
+		return Black, true
+	case "white":
+		return White, true
+	}
+	return Empty, false
+}
+
+func (c Color) String() string {
+	switch c {
+	case Black:
+		return "black"
+	case White:
+		return "white"
+	}
+	return "empty"
+}
+
+func (c Color) Opponent() Color {
+	switch c {
+	case Black:
+		return White
+	case White:
+		return Black
+	}
+	return Empty
+}
+
+type Position struct {
+	Col int `json:"col"`
+	Row int `json:"row"`
+}
+
+// Square returns the bit index of (col, row): a1 is 0, h1 is 7, h8 is 63.
+func Square(col, row int) int {
+	return row*Size + col
+}
+
+// PositionOf is the inverse of Square.
+func PositionOf(sq int) Position {
+	return Position{Col: sq % Size, Row: sq / Size}
+}
+
+// ParseSquare reads a square in standard notation such as "f5".
+func ParseSquare(s string) (Position, bool) {
+	if len(s) != 2 || s[0] < 'a' || s[0] >= 'a'+Size || s[1] < '1' || s[1] >= '1'+Size {
+		return Position{}, false
+	}
+	return Position{Col: int(s[0] - 'a'), Row: int(s[1] - '1')}, true
+}
+
+// String returns the standard notation of p, such as "f5".
+func (p Position) String() string {
+	return string([]byte{byte('a' + p.Col), byte('1' + p.Row)})
+}
+
+// Board is a bitboard: one 64-bit mask per color, bit Square(col, row) set
+// when that color occupies the square. The zero value is an empty board and
+// boards may be copied by value.
+type Board struct {
+	black uint64
+	white uint64
+}
+
+// New returns a board with the standard initial setup
+// (d4: white, e4: black, d5: black, e5: white).
+func New() *Board {
+	b := &Board{}
+	b.Set(3, 3, White)
+	b.Set(4, 3, Black)
+	b.Set(3, 4, Black)
+	b.Set(4, 4, White)
+	return b
+}
+
+func inBounds(col, row int) bool {
+	return col >= 0 && col < Size && row >= 0 && row < Size
+}
+
+// Set puts c (or Empty) on (col, row) without applying any rules,
+// for building arbitrary positions.
+func (b *Board) Set(col, row int, c Color) {
+	bit := uint64(1) << Square(col, row)
+	b.black &^= bit
+	b.white &^= bit
+	switch c {
+	case Black:
+		b.black |= bit
+	case White:
+		b.white |= bit
+	}
+}
+
+func (b *Board) At(col, row int) Color {
+	if !inBounds(col, row) {
+		return Empty
+	}
+	bit := uint64(1) << Square(col, row)
+	switch {
+	case b.black&bit != 0:
+		return Black
+	case b.white&bit != 0:
+		return White
+	}
+	return Empty
+}
+
+// Bits returns the occupancy mask of c, or of the empty squares for Empty.
+func (b *Board) Bits(c Color) uint64 {
+	switch c {
+	case Black:
+		return b.black
+	case White:
+		return b.white
+	}
+	return ^(b.black | b.white)
+}
+
+func (b *Board) players(c Color) (own, opp uint64) {
+	if c == White {
+		return b.white, b.black
+	}
+	return b.black, b.white
+}
+
+const (
+	notAFile = 0xfefefefefefefefe
+	notHFile = 0x7f7f7f7f7f7f7f7f
+)
+
+// shift moves every bit of x one step in direction d, dropping bits that
+// would wrap around the board edge.
+func shift(x uint64, d int) uint64 {
+	switch d {
+	case 0: // east
+		return (x << 1) & notAFile
+	case 1: // west
+		return (x >> 1) & notHFile
+	case 2: // south
+		return x << 8
+	case 3: // north
+		return x >> 8
+	case 4: // south-east
+		return (x << 9) & notAFile
+	case 5: // south-west
+		return (x << 7) & notHFile
+	case 6: // north-east
+		return (x >> 7) & notAFile
+	default: // north-west
+		return (x >> 9) & notHFile
+	}
+}
+
+func legalMoves(own, opp uint64) uint64 {
+	empty := ^(own | opp)
+	var moves uint64
+	for d := 0; d < 8; d++ {
+		x := shift(own, d) & opp
+		x |= shift(x, d) & opp
+		x |= shift(x, d) & opp
+		x |= shift(x, d) & opp
+		x |= shift(x, d) & opp
+		x |= shift(x, d) & opp
+		moves |= shift(x, d) & empty
+	}
+	return moves
+}
+
+func flipMask(own, opp uint64, sq int) uint64 {
+	m := uint64(1) << sq
+	if (own|opp)&m != 0 {
+		return 0
+	}
+	var flips uint64
+	for d := 0; d < 8; d++ {
+		var line uint64
+		x := shift(m, d)
+		for x&opp != 0 {
+			line |= x
+			x = shift(x, d)
+		}
+		if x&own != 0 {
+			flips |= line
+		}
+	}
+	return flips
+}
+
+// Moves returns the mask of squares where c has a legal move.
+func (b *Board) Moves(c Color) uint64 {
+	own, opp := b.players(c)
+	return legalMoves(own, opp)
+}
+
+// FlipMask returns the stones that c would turn over by playing at sq.
+// Zero means the move is illegal.
+func (b *Board) FlipMask(c Color, sq int) uint64 {
+	own, opp := b.players(c)
+	return flipMask(own, opp, sq)
+}
+
+// MakeMove plays c at sq without validation and returns the flipped mask.
+// Callers must pass a square taken from Moves.
+func (b *Board) MakeMove(c Color, sq int) uint64 {
+	flips := b.FlipMask(c, sq)
+	m := uint64(1) << sq
+	if c == White {
+		b.white |= m | flips
+		b.black &^= flips
+	} else {
+		b.black |= m | flips
+		b.white &^= flips
+	}
+	return flips
+}
+
+// Flips returns the opponent stones that would be turned over if c played at (col, row).
+// An empty result means the move is illegal.
+func (b *Board) Flips(c Color, col, row int) []Position {
+	if !inBounds(col, row) {
+		return nil
+	}
+	return positions(b.FlipMask(c, Square(col, row)))
+}
+
+func (b *Board) IsValidMove(c Color, col, row int) bool {
+	if !inBounds(col, row) {
+		return false
+	}
+	return b.Moves(c)&(uint64(1)<<Square(col, row)) != 0
+}
+
+// ValidMoves lists the legal moves for c in row-major order.
+func (b *Board) ValidMoves(c Color) []Position {
+	return positions(b.Moves(c))
+}
+
+func (b *Board) HasValidMove(c Color) bool {
+	return b.Moves(c) != 0
+}
+
+// Place puts a stone of color c at (col, row) and flips the bracketed stones.
+// The board is left untouched when the move is illegal.
+func (b *Board) Place(c Color, col, row int) error {
+	if !inBounds(col, row) {
+		return ErrOutOfRange
+	}
+	sq := Square(col, row)
+	if (b.black|b.white)&(uint64(1)<<sq) != 0 {
+		return ErrOccupied
+	}
+	if b.FlipMask(c, sq) == 0 {
+		return ErrNoFlips
+	}
+	b.MakeMove(c, sq)
+	return nil
+}
+
+func (b *Board) Count(c Color) int {
+	return bits.OnesCount64(b.Bits(c))
+}
+
+func (b *Board) IsFull() bool {
+	return b.black|b.white == ^uint64(0)
+}
+
+func positions(mask uint64) []Position {
+	var ps []Position
+	for mask != 0 {
+		sq := bits.TrailingZeros64(mask)
+		ps = append(ps, PositionOf(sq))
+		mask &= mask - 1
+	}
+	return ps
+}
@@ -0,0 +1,163 @@
+package board
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	b := New()
+	if b.At(3, 3) != White || b.At(4, 4) != White {
+		t.Fatal("expected d4 and e5 to be white")
+	}
+	if b.At(4, 3) != Black || b.At(3, 4) != Black {
+		t.Fatal("expected e4 and d5 to be black")
+	}
+	if b.Count(Black) != 2 || b.Count(White) != 2 {
+		t.Fatalf("expected 2 black and 2 white, got %d and %d", b.Count(Black), b.Count(White))
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	if c, ok := ParseColor("black"); !ok || c != Black {
+		t.Fatalf("expected black, got %v", c)
+	}
+	if c, ok := ParseColor("white"); !ok || c != White {
+		t.Fatalf("expected white, got %v", c)
+	}
+	if _, ok := ParseColor("red"); ok {
+		t.Fatal("expected red to be rejected")
+	}
+}
+
+func TestOpponent(t *testing.T) {
+	if Black.Opponent() != White || White.Opponent() != Black {
+		t.Fatal("expected black and white to be opponents")
+	}
+	if Empty.Opponent() != Empty {
+		t.Fatal("expected empty to have no opponent")
+	}
+}
+
+func TestValidMoves_Initial(t *testing.T) {
+	b := New()
+	moves := b.ValidMoves(Black)
+	expected := []Position{{Col: 3, Row: 2}, {Col: 2, Row: 3}, {Col: 5, Row: 4}, {Col: 4, Row: 5}}
+	if len(moves) != len(expected) {
+		t.Fatalf("expected %d moves, got %d", len(expected), len(moves))
+	}
+	for i, m := range moves {
+		if m != expected[i] {
+			t.Fatalf("expected move %v, got %v", expected[i], m)
+		}
+	}
+}
+
+func TestPlace(t *testing.T) {
+	b := New()
+	if err := b.Place(Black, 2, 3); err != nil {
+		t.Fatalf("expected legal move, got %v", err)
+	}
+	if b.At(2, 3) != Black || b.At(3, 3) != Black {
+		t.Fatal("expected c4 placed and d4 flipped to black")
+	}
+	if b.Count(Black) != 4 || b.Count(White) != 1 {
+		t.Fatalf("expected 4 black and 1 white, got %d and %d", b.Count(Black), b.Count(White))
+	}
+}
+
+func TestPlace_MultipleDirections(t *testing.T) {
+	b := &Board{}
+	b.Set(1, 0, White)
+	b.Set(0, 1, White)
+	b.Set(1, 1, White)
+	b.Set(2, 0, Black)
+	b.Set(0, 2, Black)
+	b.Set(2, 2, Black)
+
+	if err := b.Place(Black, 0, 0); err != nil {
+		t.Fatalf("expected legal move, got %v", err)
+	}
+	if b.Count(White) != 0 {
+		t.Fatalf("expected all 3 white stones flipped, %d left", b.Count(White))
+	}
+}
+
+func TestPlace_Occupied(t *testing.T) {
+	b := New()
+	if err := b.Place(Black, 3, 3); !errors.Is(err, ErrOccupied) {
+		t.Fatalf("expected ErrOccupied, got %v", err)
+	}
+}
+
+func TestPlace_NoFlips(t *testing.T) {
+	b := New()
+	if err := b.Place(Black, 0, 0); !errors.Is(err, ErrNoFlips) {
+		t.Fatalf("expected ErrNoFlips, got %v", err)
+	}
+	if b.Count(Black) != 2 {
+		t.Fatal("expected board to be unchanged after illegal move")
+	}
+}
+
+func TestPlace_OutOfRange(t *testing.T) {
+	b := New()
+	if err := b.Place(Black, 8, 0); !errors.Is(err, ErrOutOfRange) {
+		t.Fatalf("expected ErrOutOfRange, got %v", err)
+	}
+}
+
+func TestPlace_GapBreaksBracket(t *testing.T) {
+	b := &Board{}
+	b.Set(1, 0, White)
+	b.Set(3, 0, Black)
+	if b.IsValidMove(Black, 0, 0) {
+		t.Fatal("expected empty square to break the bracket")
+	}
+}
+
+func TestHasValidMove(t *testing.T) {
+	b := New()
+	if !b.HasValidMove(Black) || !b.HasValidMove(White) {
+		t.Fatal("expected both colors to have moves at start")
+	}
+	empty := &Board{}
+	if empty.HasValidMove(Black) {
+		t.Fatal("expected no moves on an empty board")
+	}
+}
+
+func TestIsFull(t *testing.T) {
+	b := New()
+	if b.IsFull() {
+		t.Fatal("expected initial board not to be full")
+	}
+	full := &Board{}
+	for row := 0; row < Size; row++ {
+		for col := 0; col < Size; col++ {
+			full.Set(col, row, Black)
+		}
+	}
+	if !full.IsFull() {
+		t.Fatal("expected board to be full")
+	}
+}
+
+func TestParseSquare(t *testing.T) {
+	for col := 0; col < Size; col++ {
+		for row := 0; row < Size; row++ {
+			p := Position{Col: col, Row: row}
+			if got, ok := ParseSquare(p.String()); !ok || got != p {
+				t.Fatalf("expected %v back from %s, got %v", p, p, got)
+			}
+		}
+	}
+	if s := (Position{Col: 5, Row: 4}).String(); s != "f5" {
+		t.Fatalf("expected f5, got %s", s)
+	}
+	for _, s := range []string{"", "f", "i1", "a9", "a0", "F5", "f55"} {
+		if _, ok := ParseSquare(s); ok {
+			t.Fatalf("expected %q to be rejected", s)
+		}
+	}
+}
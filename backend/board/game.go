@@ -0,0 +1,79 @@
+package board
+
+import "errors"
+
+var (
+	ErrGameOver       = errors.New("game is already over")
+	ErrNotYourTurn    = errors.New("not your turn")
+	ErrPassNotAllowed = errors.New("pass is only allowed when no legal move exists")
+)
+
+// Game tracks a board together with the side to move.
+// Turn is Empty once neither side can move.
+type Game struct {
+	Board *Board
+	Turn  Color
+}
+
+func NewGame() *Game {
+	return &Game{Board: New(), Turn: Black}
+}
+
+// Play places a stone for c and hands the turn to the opponent.
+// It does not apply passes on its own; callers check MustPass afterwards.
+func (g *Game) Play(c Color, col, row int) error {
+	if g.Turn == Empty {
+		return ErrGameOver
+	}
+	if c != g.Turn {
+		return ErrNotYourTurn
+	}
+	if err := g.Board.Place(c, col, row); err != nil {
+		return err
+	}
+	g.Turn = c.Opponent()
+	g.updateOver()
+	return nil
+}
+
+// Pass records that c had no legal move and hands the turn back.
+func (g *Game) Pass(c Color) error {
+	if g.Turn == Empty {
+		return ErrGameOver
+	}
+	if c != g.Turn {
+		return ErrNotYourTurn
+	}
+	if g.Board.HasValidMove(c) {
+		return ErrPassNotAllowed
+	}
+	g.Turn = c.Opponent()
+	return nil
+}
+
+// MustPass reports whether the side to move has no legal move and has to pass.
+func (g *Game) MustPass() bool {
+	return g.Turn != Empty && !g.Board.HasValidMove(g.Turn)
+}
+
+func (g *Game) IsOver() bool {
+	return g.Turn == Empty
+}
+
+func (g *Game) updateOver() {
+	if g.Board.IsFull() || (!g.Board.HasValidMove(Black) && !g.Board.HasValidMove(White)) {
+		g.Turn = Empty
+	}
+}
+
+// Result names the outcome of a game that ended blackCount to whiteCount:
+// "black_win", "white_win" or "draw".
+func Result(blackCount, whiteCount int) string {
+	if blackCount > whiteCount {
+		return "black_win"
+	}
+	if whiteCount > blackCount {
+		return "white_win"
+	}
+	return "draw"
+}
@@ -0,0 +1,96 @@
+package board
+
+import (
+	"errors"
+	"testing"
+)
+
+// passOpening is a legal sequence after which black has no move and must pass.
+var passOpening = [][2]int{{3, 2}, {2, 2}, {1, 2}, {1, 1}, {5, 4}, {0, 2}, {0, 0}, {2, 0}}
+
+func TestNewGame(t *testing.T) {
+	g := NewGame()
+	if g.Turn != Black {
+		t.Fatalf("expected black to move first, got %v", g.Turn)
+	}
+	if g.IsOver() || g.MustPass() {
+		t.Fatal("expected a fresh game to be in progress without a pass")
+	}
+}
+
+func TestGamePlay_AlternatesTurn(t *testing.T) {
+	g := NewGame()
+	if err := g.Play(Black, 2, 3); err != nil {
+		t.Fatalf("expected legal move, got %v", err)
+	}
+	if g.Turn != White {
+		t.Fatalf("expected white to move, got %v", g.Turn)
+	}
+}
+
+func TestGamePlay_NotYourTurn(t *testing.T) {
+	g := NewGame()
+	if err := g.Play(White, 2, 3); !errors.Is(err, ErrNotYourTurn) {
+		t.Fatalf("expected ErrNotYourTurn, got %v", err)
+	}
+}
+
+func TestGamePass(t *testing.T) {
+	g := NewGame()
+	for _, m := range passOpening {
+		if err := g.Play(g.Turn, m[0], m[1]); err != nil {
+			t.Fatalf("expected legal move %v, got %v", m, err)
+		}
+	}
+	if !g.MustPass() || g.Turn != Black {
+		t.Fatalf("expected black to have to pass, turn %v", g.Turn)
+	}
+	if err := g.Pass(Black); err != nil {
+		t.Fatalf("expected pass to be accepted, got %v", err)
+	}
+	if g.Turn != White {
+		t.Fatalf("expected white to move after pass, got %v", g.Turn)
+	}
+}
+
+func TestGamePass_NotAllowed(t *testing.T) {
+	g := NewGame()
+	if err := g.Pass(Black); !errors.Is(err, ErrPassNotAllowed) {
+		t.Fatalf("expected ErrPassNotAllowed, got %v", err)
+	}
+}
+
+func TestGame_OverWhenNoOneCanMove(t *testing.T) {
+	b := &Board{}
+	b.Set(0, 0, Black)
+	b.Set(1, 0, White)
+	b.Set(3, 0, Black)
+	g := &Game{Board: b, Turn: Black}
+
+	// Black at c1 wipes out white, leaving no moves for either side
+	if err := g.Play(Black, 2, 0); err != nil {
+		t.Fatalf("expected legal move, got %v", err)
+	}
+	if !g.IsOver() {
+		t.Fatal("expected game to be over")
+	}
+	if err := g.Play(White, 4, 0); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("expected ErrGameOver, got %v", err)
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		black, white int
+		want         string
+	}{
+		{40, 24, "black_win"},
+		{0, 64, "white_win"},
+		{32, 32, "draw"},
+	}
+	for _, tt := range tests {
+		if got := Result(tt.black, tt.white); got != tt.want {
+			t.Fatalf("%d-%d: expected %s, got %s", tt.black, tt.white, tt.want, got)
+		}
+	}
+}
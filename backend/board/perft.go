@@ -0,0 +1,32 @@
+package board
+
+import "math/bits"
+
+// Perft counts the move paths of the given depth from b with c to move.
+// A forced pass counts as a ply, and a finished game counts as a single
+// leaf regardless of the remaining depth.
+func Perft(b Board, c Color, depth int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	moves := b.Moves(c)
+	if moves == 0 {
+		if b.Moves(c.Opponent()) == 0 {
+			return 1
+		}
+		return Perft(b, c.Opponent(), depth-1)
+	}
+	if depth == 1 {
+		return uint64(bits.OnesCount64(moves))
+	}
+
+	var nodes uint64
+	for moves != 0 {
+		sq := bits.TrailingZeros64(moves)
+		moves &= moves - 1
+		next := b
+		next.MakeMove(c, sq)
+		nodes += Perft(next, c.Opponent(), depth-1)
+	}
+	return nodes
+}
@@ -0,0 +1,80 @@
+package board
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPerft(t *testing.T) {
+	expected := []uint64{1, 4, 12, 56, 244, 1396, 8200, 55092, 390216, 3005288}
+	for depth, want := range expected {
+		if testing.Short() && depth > 8 {
+			break
+		}
+		if got := Perft(*New(), Black, depth); got != want {
+			t.Fatalf("perft(%d): expected %d, got %d", depth, want, got)
+		}
+	}
+}
+
+// naiveMoves is a direct scan over the 8 directions used to cross-check the
+// shift-based generator.
+func naiveMoves(b *Board, c Color) uint64 {
+	var moves uint64
+	for row := 0; row < Size; row++ {
+		for col := 0; col < Size; col++ {
+			if b.At(col, row) != Empty {
+				continue
+			}
+			for _, d := range [8][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}} {
+				n := 0
+				cl, r := col+d[0], row+d[1]
+				for inBounds(cl, r) && b.At(cl, r) == c.Opponent() {
+					n++
+					cl += d[0]
+					r += d[1]
+				}
+				if n > 0 && inBounds(cl, r) && b.At(cl, r) == c {
+					moves |= uint64(1) << Square(col, row)
+					break
+				}
+			}
+		}
+	}
+	return moves
+}
+
+func TestMoves_MatchesNaiveScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for game := 0; game < 200; game++ {
+		b := New()
+		c := Black
+		for {
+			if got, want := b.Moves(c), naiveMoves(b, c); got != want {
+				t.Fatalf("game %d: expected moves %016x, got %016x", game, want, got)
+			}
+			moves := b.ValidMoves(c)
+			if len(moves) == 0 {
+				if !b.HasValidMove(c.Opponent()) {
+					break
+				}
+				c = c.Opponent()
+				continue
+			}
+			m := moves[rng.Intn(len(moves))]
+			if err := b.Place(c, m.Col, m.Row); err != nil {
+				t.Fatalf("game %d: expected legal move, got %v", game, err)
+			}
+			c = c.Opponent()
+		}
+		if b.Count(Black)+b.Count(White)+b.Count(Empty) != 64 {
+			t.Fatalf("game %d: expected 64 squares in total", game)
+		}
+	}
+}
+
+func BenchmarkPerft8(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Perft(*New(), Black, 8)
+	}
+}
@@ -0,0 +1,84 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A position string lists the 64 squares from a1 to h8, rank by rank, as X
+// for black, O for white and - for empty, followed by the side to move (X or
+// O, or - once neither side can move), as in StartPosition. Whitespace is
+// ignored when parsing, so the ranks may be written on lines of their own,
+// and letters may be lower case.
+const positionLength = Size*Size + 1
+
+// StartPosition is the standard starting position with black to move.
+const StartPosition = "---------------------------OX------XO---------------------------X"
+
+// ParsePosition reads a position string.
+func ParsePosition(s string) (*Game, error) {
+	s = strings.Join(strings.Fields(strings.ToUpper(s)), "")
+	if len(s) != positionLength {
+		return nil, fmt.Errorf("position must have %d squares and the side to move", Size*Size)
+	}
+
+	b := &Board{}
+	for sq := 0; sq < Size*Size; sq++ {
+		c, ok := positionColor(s[sq])
+		p := PositionOf(sq)
+		if !ok {
+			return nil, fmt.Errorf("invalid square %q at %s", s[sq], p)
+		}
+		b.Set(p.Col, p.Row, c)
+	}
+	turn, ok := positionColor(s[Size*Size])
+	if !ok {
+		return nil, fmt.Errorf("invalid side to move %q", s[Size*Size])
+	}
+
+	g := &Game{Board: b, Turn: turn}
+	if turn == Empty {
+		// updateOver only ever clears the turn, so start from a side
+		g.Turn = Black
+		if g.updateOver(); !g.IsOver() {
+			return nil, errors.New("position needs a side to move")
+		}
+	}
+	g.updateOver()
+	return g, nil
+}
+
+// FormatPosition writes the position string of g.
+func FormatPosition(g *Game) string {
+	var sb strings.Builder
+	sb.Grow(positionLength)
+	for sq := 0; sq < Size*Size; sq++ {
+		p := PositionOf(sq)
+		sb.WriteByte(positionChar(g.Board.At(p.Col, p.Row)))
+	}
+	sb.WriteByte(positionChar(g.Turn))
+	return sb.String()
+}
+
+func positionColor(ch byte) (Color, bool) {
+	switch ch {
+	case 'X':
+		return Black, true
+	case 'O':
+		return White, true
+	case '-':
+		return Empty, true
+	}
+	return Empty, false
+}
+
+func positionChar(c Color) byte {
+	switch c {
+	case Black:
+		return 'X'
+	case White:
+		return 'O'
+	}
+	return '-'
+}
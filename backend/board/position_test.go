@@ -0,0 +1,67 @@
+package board
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatPosition_Start(t *testing.T) {
+	if got := FormatPosition(NewGame()); got != StartPosition {
+		t.Fatalf("expected %s, got %s", StartPosition, got)
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	// black to move has only the corner, white owns the rest of the edge
+	s := "-OOOOOOX\n" + strings.Repeat("--------\n", 7) + "x"
+	g, err := ParsePosition(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Turn != Black || g.Board.At(7, 0) != Black || g.Board.At(1, 0) != White || g.Board.At(0, 1) != Empty {
+		t.Fatalf("expected the parsed board with black to move, got %s", FormatPosition(g))
+	}
+	if err := g.Play(Black, 0, 0); err != nil {
+		t.Fatalf("expected a1 to be legal, got %v", err)
+	}
+	if g.Board.Count(Black) != 8 || !g.IsOver() {
+		t.Fatalf("expected black to take the rank and end the game, got %s", FormatPosition(g))
+	}
+	if got := FormatPosition(g); got != "XXXXXXXX"+strings.Repeat("-", 56)+"-" {
+		t.Fatalf("expected a finished position, got %s", got)
+	}
+}
+
+func TestParsePosition_RoundTrip(t *testing.T) {
+	g := NewGame()
+	for _, m := range passOpening {
+		if err := g.Play(g.Turn, m[0], m[1]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	parsed, err := ParsePosition(FormatPosition(g))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *parsed.Board != *g.Board || parsed.Turn != g.Turn || !parsed.MustPass() {
+		t.Fatalf("expected the same position with black to pass, got %s", FormatPosition(parsed))
+	}
+}
+
+func TestParsePosition_Errors(t *testing.T) {
+	tests := []struct {
+		position, want string
+	}{
+		{StartPosition[:64], "64 squares"},
+		{StartPosition + "X", "64 squares"},
+		{"*" + StartPosition[1:], "invalid square '*' at a1"},
+		{StartPosition[:64] + "B", "invalid side to move"},
+		{StartPosition[:64] + "-", "needs a side to move"},
+	}
+	for _, tt := range tests {
+		_, err := ParsePosition(tt.position)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tt.position, tt.want, err)
+		}
+	}
+}
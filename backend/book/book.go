@@ -0,0 +1,234 @@
+package book
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"strings"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+//go:embed openings.txt
+var defaultBook string
+
+// Book is a set of named opening lines. Positions are stored in a canonical
+// orientation so that lines reached through any of the board's eight
+// symmetries, or through a different move order, are recognised.
+type Book struct {
+	entries map[key]*entry
+}
+
+type key struct {
+	black, white uint64
+	turn         board.Color
+}
+
+type entry struct {
+	name string
+	// moves are the book continuations in canonical orientation, in file order.
+	moves []int
+}
+
+// Default returns the book shipped with the server.
+func Default() *Book {
+	bk, err := Parse(strings.NewReader(defaultBook))
+	if err != nil {
+		panic("book: invalid built-in opening book: " + err.Error())
+	}
+	return bk
+}
+
+// Load reads a book file from disk.
+func Load(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a book in the openings.txt format: each non-empty line that does
+// not start with '#' holds a move sequence such as "f5d6c3d3c4" followed by
+// the opening name.
+func Parse(r io.Reader) (*Book, error) {
+	bk := &Book{entries: map[key]*entry{}}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		seq, name, _ := strings.Cut(line, " ")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("line %d: missing opening name", n)
+		}
+		if err := bk.add(seq, name); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return bk, nil
+}
+
+func (bk *Book) add(seq, name string) error {
+	if len(seq)%2 != 0 {
+		return fmt.Errorf("malformed move sequence %q", seq)
+	}
+	b, c := *board.New(), board.Black
+	for i := 0; i < len(seq); i += 2 {
+		p, ok := board.ParseSquare(seq[i : i+2])
+		if !ok {
+			return fmt.Errorf("malformed move %q", seq[i:i+2])
+		}
+		sq := board.Square(p.Col, p.Row)
+		if b.Moves(c)&(uint64(1)<<sq) == 0 {
+			return fmt.Errorf("illegal move %s", seq[i:i+2])
+		}
+		k, s := canonical(b, c)
+		e := bk.entry(k)
+		m := bits.TrailingZeros64(transform(s, uint64(1)<<sq))
+		if !contains(e.moves, m) {
+			e.moves = append(e.moves, m)
+		}
+		b.MakeMove(c, sq)
+		c = c.Opponent()
+	}
+	k, _ := canonical(b, c)
+	if e := bk.entry(k); e.name == "" {
+		e.name = name
+	}
+	return nil
+}
+
+func (bk *Book) entry(k key) *entry {
+	e, ok := bk.entries[k]
+	if !ok {
+		e = &entry{}
+		bk.entries[k] = e
+	}
+	return e
+}
+
+// Move returns the main book continuation for c in b, if b is a book position.
+func (bk *Book) Move(b board.Board, c board.Color) (board.Position, bool) {
+	k, s := canonical(b, c)
+	e, ok := bk.entries[k]
+	if !ok || len(e.moves) == 0 {
+		return board.Position{}, false
+	}
+	sq := bits.TrailingZeros64(inverse(s, uint64(1)<<e.moves[0]))
+	return board.PositionOf(sq), true
+}
+
+// Name returns the longest named opening matched by a game that starts from
+// the initial position and plays squares in order, or "" when none matches.
+// Lookup stops at the first move that leaves the book or at a Pass (-1).
+func (bk *Book) Name(squares []int) string {
+	b, c := *board.New(), board.Black
+	name := ""
+	for _, sq := range squares {
+		if sq < 0 || b.Moves(c)&(uint64(1)<<sq) == 0 {
+			break
+		}
+		b.MakeMove(c, sq)
+		c = c.Opponent()
+		k, _ := canonical(b, c)
+		e, ok := bk.entries[k]
+		if !ok {
+			break
+		}
+		if e.name != "" {
+			name = e.name
+		}
+	}
+	return name
+}
+
+func contains(moves []int, m int) bool {
+	for _, x := range moves {
+		if x == m {
+			return true
+		}
+	}
+	return false
+}
+
+// canonical returns the smallest of the eight symmetric images of b and the
+// symmetry that produces it.
+func canonical(b board.Board, c board.Color) (key, int) {
+	black, white := b.Bits(board.Black), b.Bits(board.White)
+	best, bestSym := key{}, -1
+	for s := 0; s < 8; s++ {
+		k := key{black: transform(s, black), white: transform(s, white), turn: c}
+		if bestSym < 0 || k.black < best.black || (k.black == best.black && k.white < best.white) {
+			best, bestSym = k, s
+		}
+	}
+	return best, bestSym
+}
+
+// transform applies symmetry s (0-7) to a bitboard: bit 2 transposes,
+// bit 1 flips the rows and bit 0 mirrors the columns.
+func transform(s int, x uint64) uint64 {
+	if s&4 != 0 {
+		x = transpose(x)
+	}
+	if s&2 != 0 {
+		x = bits.ReverseBytes64(x)
+	}
+	if s&1 != 0 {
+		x = mirror(x)
+	}
+	return x
+}
+
+// inverse undoes transform(s, x).
+func inverse(s int, x uint64) uint64 {
+	if s&1 != 0 {
+		x = mirror(x)
+	}
+	if s&2 != 0 {
+		x = bits.ReverseBytes64(x)
+	}
+	if s&4 != 0 {
+		x = transpose(x)
+	}
+	return x
+}
+
+// mirror swaps columns a and h, b and g, and so on.
+func mirror(x uint64) uint64 {
+	const (
+		k1 = 0x5555555555555555
+		k2 = 0x3333333333333333
+		k4 = 0x0f0f0f0f0f0f0f0f
+	)
+	x = ((x >> 1) & k1) | ((x & k1) << 1)
+	x = ((x >> 2) & k2) | ((x & k2) << 2)
+	x = ((x >> 4) & k4) | ((x & k4) << 4)
+	return x
+}
+
+// transpose swaps columns and rows (reflection in the a1-h8 diagonal).
+func transpose(x uint64) uint64 {
+	const (
+		k1 = 0x5500550055005500
+		k2 = 0x3333000033330000
+		k4 = 0x0f0f0f0f00000000
+	)
+	t := k4 & (x ^ (x << 28))
+	x ^= t ^ (t >> 28)
+	t = k2 & (x ^ (x << 14))
+	x ^= t ^ (t >> 14)
+	t = k1 & (x ^ (x << 7))
+	x ^= t ^ (t >> 7)
+	return x
+}
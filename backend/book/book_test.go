@@ -0,0 +1,134 @@
+package book
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+func squares(t *testing.T, seq string) []int {
+	t.Helper()
+	var out []int
+	for i := 0; i < len(seq); i += 2 {
+		p, ok := board.ParseSquare(seq[i : i+2])
+		if !ok {
+			t.Fatalf("expected a square, got %q", seq[i:i+2])
+		}
+		out = append(out, board.Square(p.Col, p.Row))
+	}
+	return out
+}
+
+func TestDefault(t *testing.T) {
+	bk := Default()
+	if len(bk.entries) == 0 {
+		t.Fatal("expected a non-empty book")
+	}
+}
+
+func TestName(t *testing.T) {
+	bk := Default()
+	tests := []struct {
+		seq  string
+		want string
+	}{
+		{"", ""},
+		{"f5", ""},
+		{"f5d6", "Perpendicular Opening"},
+		{"f5d6c3d3c4", "Tiger"},
+		{"f5d6c3d3c4f4f6", "Leader's Tiger"},
+		// leaving the book keeps the last named opening
+		{"f5d6c3d3c4b4", "Tiger"},
+		// the same line reflected in the a1-h8 diagonal
+		{"e6f4c3c4d3", "Tiger"},
+		// rotated by 180 degrees
+		{"c4e3f6e6f5", "Tiger"},
+		{"f5f6e6f4c3", "Buffalo"},
+		{"f5d6c5f4e3c6d3f6e6d7", "Rose"},
+	}
+	for _, tt := range tests {
+		if got := bk.Name(squares(t, tt.seq)); got != tt.want {
+			t.Fatalf("%q: expected %q, got %q", tt.seq, tt.want, got)
+		}
+	}
+}
+
+func TestName_StopsAtPass(t *testing.T) {
+	bk := Default()
+	seq := append(squares(t, "f5d6"), -1)
+	seq = append(seq, squares(t, "c3d3c4")...)
+	if got := bk.Name(seq); got != "Perpendicular Opening" {
+		t.Fatalf("expected Perpendicular Opening, got %q", got)
+	}
+}
+
+func TestMove(t *testing.T) {
+	bk := Default()
+	tests := []struct {
+		seq  string
+		want string
+	}{
+		{"f5", "d6"},
+		{"e6", "f4"},
+		{"f5d6c3", "d3"},
+		{"d3c3c4", "c5"},
+	}
+	for _, tt := range tests {
+		b, c := *board.New(), board.Black
+		for _, sq := range squares(t, tt.seq) {
+			b.MakeMove(c, sq)
+			c = c.Opponent()
+		}
+		pos, ok := bk.Move(b, c)
+		if !ok || pos.String() != tt.want {
+			t.Fatalf("%q: expected %s, got %v (%v)", tt.seq, tt.want, pos, ok)
+		}
+	}
+}
+
+func TestMove_OutOfBook(t *testing.T) {
+	bk := Default()
+	b, c := *board.New(), board.Black
+	for _, sq := range squares(t, "f5d6c3d3c4b4") {
+		b.MakeMove(c, sq)
+		c = c.Opponent()
+	}
+	if _, ok := bk.Move(b, c); ok {
+		t.Fatal("expected no book move")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []string{
+		"f5d6",
+		"f5d5 Bad",
+		"f5d Bad",
+		"z9 Bad",
+	}
+	for _, in := range tests {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Fatalf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	for s := 0; s < 8; s++ {
+		for sq := 0; sq < 64; sq++ {
+			x := uint64(1) << sq
+			if inverse(s, transform(s, x)) != x {
+				t.Fatalf("symmetry %d: expected inverse to restore square %d", s, sq)
+			}
+		}
+	}
+	for sq := 0; sq < 64; sq++ {
+		p := board.PositionOf(sq)
+		if transpose(uint64(1)<<sq) != uint64(1)<<board.Square(p.Row, p.Col) {
+			t.Fatalf("expected transpose to swap col and row of square %d", sq)
+		}
+		if mirror(uint64(1)<<sq) != uint64(1)<<board.Square(7-p.Col, p.Row) {
+			t.Fatalf("expected mirror to swap columns of square %d", sq)
+		}
+	}
+}
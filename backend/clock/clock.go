@@ -0,0 +1,125 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// Control is a game's time control. With PerMove set every move must be made
+// within it; otherwise each side starts with Base and gains Increment after
+// each of its moves.
+type Control struct {
+	Base      time.Duration
+	Increment time.Duration
+	PerMove   time.Duration
+}
+
+// Move is a recorded move or pass: who made it and when.
+type Move struct {
+	Color board.Color
+	At    time.Time
+}
+
+// State is the position of both clocks after a sequence of moves.
+type State struct {
+	black, white time.Duration
+	running      board.Color
+	since        time.Time
+}
+
+// Replay runs the clocks over the recorded moves. No time is charged for the
+// first move of the game: the clocks start once it has been made, and then the
+// side to move is the opponent of whoever moved last.
+//
+// Recorded moves were accepted by the server, so a move that arrives a little
+// late according to its timestamp leaves its side at zero rather than flagged.
+func Replay(c Control, moves []Move) State {
+	return ReplayResumed(c, board.Black, moves, time.Time{})
+}
+
+// ReplayResumed is Replay for clocks that were stopped until resumed, as when
+// the guest joins or after a takeback: the move being thought about then is
+// only charged from resumed on, while the moves made before are charged as
+// usual. Resumed before any move, the clock of first, the side to move in the
+// starting position, runs from resumed.
+func ReplayResumed(c Control, first board.Color, moves []Move, resumed time.Time) State {
+	s := State{black: c.Base, white: c.Base}
+	if c.PerMove > 0 {
+		s.black, s.white = c.PerMove, c.PerMove
+	}
+	for i, m := range moves {
+		left := s.left(m.Color)
+		var since time.Time
+		if i > 0 {
+			since = moves[i-1].At
+		}
+		if resumed.After(since) && resumed.Before(m.At) {
+			since = resumed
+		}
+		if !since.IsZero() && m.At.After(since) {
+			left -= m.At.Sub(since)
+		}
+		if left < 0 {
+			left = 0
+		}
+		if c.PerMove > 0 {
+			left = c.PerMove
+		} else {
+			left += c.Increment
+		}
+		s.set(m.Color, left)
+	}
+	if len(moves) > 0 {
+		last := moves[len(moves)-1]
+		s.running, s.since = last.Color.Opponent(), later(last.At, resumed)
+	} else if !resumed.IsZero() {
+		s.running, s.since = first, resumed
+	}
+	return s
+}
+
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// Running returns the side whose clock is running, or board.Empty while the
+// clocks have not started.
+func (s State) Running() board.Color {
+	return s.running
+}
+
+// Left returns the time c has left at now, never less than zero.
+func (s State) Left(c board.Color, now time.Time) time.Duration {
+	left := s.left(c)
+	if c == s.running {
+		left -= now.Sub(s.since)
+	}
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// Flagged reports whether the side to move has run out of time at now.
+func (s State) Flagged(now time.Time) bool {
+	return s.running != board.Empty && s.Left(s.running, now) == 0
+}
+
+func (s State) left(c board.Color) time.Duration {
+	if c == board.White {
+		return s.white
+	}
+	return s.black
+}
+
+func (s *State) set(c board.Color, d time.Duration) {
+	if c == board.White {
+		s.white = d
+	} else {
+		s.black = d
+	}
+}
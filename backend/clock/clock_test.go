@@ -0,0 +1,161 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return start.Add(time.Duration(seconds) * time.Second)
+}
+
+func TestReplay_NotStarted(t *testing.T) {
+	s := Replay(Control{Base: time.Minute}, nil)
+
+	if s.Running() != board.Empty {
+		t.Fatalf("expected no clock running, got %v", s.Running())
+	}
+	if s.Left(board.Black, at(3600)) != time.Minute {
+		t.Fatalf("expected black to keep 1m before the first move, got %v", s.Left(board.Black, at(3600)))
+	}
+	if s.Flagged(at(3600)) {
+		t.Fatal("expected no flag before the first move")
+	}
+}
+
+func TestReplay_Increment(t *testing.T) {
+	c := Control{Base: time.Minute, Increment: 2 * time.Second}
+	moves := []Move{
+		{board.Black, at(0)},
+		{board.White, at(10)},
+		{board.Black, at(15)},
+	}
+	s := Replay(c, moves)
+
+	if s.Running() != board.White {
+		t.Fatalf("expected white to be running, got %v", s.Running())
+	}
+	// black: 60 + 2 (free first move), then -5 + 2
+	if got := s.Left(board.Black, at(100)); got != 59*time.Second {
+		t.Fatalf("expected black to have 59s, got %v", got)
+	}
+	// white: 60 - 10 + 2, then running for 20s
+	if got := s.Left(board.White, at(35)); got != 32*time.Second {
+		t.Fatalf("expected white to have 32s, got %v", got)
+	}
+	if s.Flagged(at(66)) {
+		t.Fatal("expected white not to be flagged with 1s left")
+	}
+	if !s.Flagged(at(67)) {
+		t.Fatal("expected white to be flagged")
+	}
+}
+
+func TestReplay_PerMove(t *testing.T) {
+	c := Control{PerMove: 30 * time.Second}
+	moves := []Move{
+		{board.Black, at(0)},
+		{board.White, at(29)},
+	}
+	s := Replay(c, moves)
+
+	if got := s.Left(board.White, at(40)); got != 30*time.Second {
+		t.Fatalf("expected white to be back to 30s, got %v", got)
+	}
+	if got := s.Left(board.Black, at(40)); got != 19*time.Second {
+		t.Fatalf("expected black to have 19s, got %v", got)
+	}
+	if !s.Flagged(at(59)) {
+		t.Fatal("expected black to be flagged after 30s")
+	}
+}
+
+func TestReplay_LateMoveIsNotFlagged(t *testing.T) {
+	c := Control{Base: 10 * time.Second}
+	moves := []Move{
+		{board.Black, at(0)},
+		{board.White, at(11)},
+	}
+	s := Replay(c, moves)
+
+	if got := s.Left(board.White, at(11)); got != 0 {
+		t.Fatalf("expected white to be left at zero, got %v", got)
+	}
+	if s.Running() != board.Black || s.Flagged(at(11)) {
+		t.Fatal("expected black to be running and not flagged")
+	}
+}
+
+func TestReplay_Pass(t *testing.T) {
+	c := Control{Base: time.Minute}
+	moves := []Move{
+		{board.Black, at(0)},
+		{board.White, at(5)},
+		{board.Black, at(5)}, // forced pass recorded with white's move
+		{board.White, at(20)},
+	}
+	s := Replay(c, moves)
+
+	if s.Running() != board.Black {
+		t.Fatalf("expected black to be running, got %v", s.Running())
+	}
+	if got := s.Left(board.White, at(20)); got != 40*time.Second {
+		t.Fatalf("expected white to have 40s, got %v", got)
+	}
+}
+
+func TestReplayResumed(t *testing.T) {
+	c := Control{Base: time.Minute}
+	moves := []Move{
+		{board.Black, at(0)},
+		{board.White, at(40)},
+	}
+	// black thought, moved, and had the move taken back at 100
+	s := ReplayResumed(c, board.Black, moves, at(100))
+
+	if s.Running() != board.Black {
+		t.Fatalf("expected black to be running, got %v", s.Running())
+	}
+	if got := s.Left(board.Black, at(130)); got != 30*time.Second {
+		t.Fatalf("expected black to have 30s, got %v", got)
+	}
+	// white's 40s before the takeback stay charged
+	if got := s.Left(board.White, at(130)); got != 20*time.Second {
+		t.Fatalf("expected white to have 20s, got %v", got)
+	}
+
+	// the next move is charged from the resume point too
+	s = ReplayResumed(c, board.Black, append(moves, Move{board.Black, at(120)}), at(100))
+	if got := s.Left(board.Black, at(120)); got != 40*time.Second {
+		t.Fatalf("expected black to have 40s, got %v", got)
+	}
+	if got := s.Left(board.White, at(120)); got != 20*time.Second {
+		t.Fatalf("expected white to have 20s, got %v", got)
+	}
+}
+
+func TestReplayResumed_FirstMove(t *testing.T) {
+	c := Control{Base: time.Minute}
+	// the guest joined at 0 and black has not moved yet
+	s := ReplayResumed(c, board.Black, nil, at(0))
+
+	if s.Running() != board.Black {
+		t.Fatalf("expected black to be running, got %v", s.Running())
+	}
+	if s.Flagged(at(59)) || !s.Flagged(at(60)) {
+		t.Fatal("expected black to flag after a minute")
+	}
+
+	// the first move is charged from the start
+	s = ReplayResumed(c, board.Black, []Move{{board.Black, at(25)}}, at(0))
+	if got := s.Left(board.Black, at(30)); got != 35*time.Second {
+		t.Fatalf("expected black to have 35s, got %v", got)
+	}
+	if got := s.Left(board.White, at(30)); got != 55*time.Second {
+		t.Fatalf("expected white to have 55s, got %v", got)
+	}
+}
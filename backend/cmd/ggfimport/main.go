@@ -0,0 +1,85 @@
+// Command ggfimport loads the finished games of GGF files, such as game
+// server archives, into the database configured by the usual DB_*
+// environment variables. Records that cannot be read or replayed, and games
+// still in progress, are reported and skipped.
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+
+	"github.com/dog-nose/othello-backend/config"
+	"github.com/dog-nose/othello-backend/ggf"
+	"github.com/dog-nose/othello-backend/repository"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s file.ggf...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("mysql", config.Load().DSN())
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewMySQLRepository(db)
+
+	for _, path := range flag.Args() {
+		imported, skipped, err := importFile(repo, path)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		fmt.Printf("%s: imported %d games, skipped %d\n", path, imported, skipped)
+	}
+}
+
+func importFile(repo repository.Repository, path string) (imported, skipped int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	rd := ggf.NewReader(f)
+	for n := 1; ; n++ {
+		rec, err := rd.Next()
+		if errors.Is(err, io.EOF) {
+			return imported, skipped, nil
+		}
+		if err != nil {
+			log.Printf("%s: skipping record %d: %v", path, n, err)
+			skipped++
+			continue
+		}
+
+		game, moves, err := rec.Game()
+		if err == nil && game.Result == nil {
+			err = errors.New("game is unfinished")
+		}
+		if err != nil {
+			log.Printf("%s: skipping record %d: %v", path, n, err)
+			skipped++
+			continue
+		}
+		game.PlayID = uuid.New().String()
+		if err := repo.ImportGame(game, moves); err != nil {
+			return imported, skipped, err
+		}
+		imported++
+	}
+}
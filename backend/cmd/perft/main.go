@@ -0,0 +1,25 @@
+// Command perft enumerates move paths from the standard start position
+// and prints the node count for each depth.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+func main() {
+	depth := flag.Int("depth", 9, "maximum depth to search")
+	flag.Parse()
+
+	start := board.New()
+	for d := 1; d <= *depth; d++ {
+		began := time.Now()
+		nodes := board.Perft(*start, board.Black, d)
+		elapsed := time.Since(began)
+		nps := float64(nodes) / elapsed.Seconds()
+		fmt.Printf("perft(%2d) = %12d  %10s  %.0f nodes/s\n", d, nodes, elapsed.Round(time.Millisecond), nps)
+	}
+}
@@ -0,0 +1,122 @@
+// Command wthorimport loads the games of WTHOR database files (.wtb) into the
+// reference_games table of the database configured by the usual DB_*
+// environment variables. The player (.jou) and tournament (.trn) files give
+// the names; without them, games are stored unnamed. Every game is replayed
+// and those breaking the rules or with a wrong score are reported and
+// skipped. Importing a file again only adds the games that are missing.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/dog-nose/othello-backend/config"
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/repository"
+	"github.com/dog-nose/othello-backend/wthor"
+)
+
+func main() {
+	playersPath := flag.String("players", "", "player names file (WTHOR.JOU)")
+	tournamentsPath := flag.String("tournaments", "", "tournament names file (WTHOR.TRN)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-players WTHOR.JOU] [-tournaments WTHOR.TRN] file.wtb...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	players, err := readNames(*playersPath, wthor.ReadPlayers)
+	if err != nil {
+		log.Fatalf("%s: %v", *playersPath, err)
+	}
+	tournaments, err := readNames(*tournamentsPath, wthor.ReadTournaments)
+	if err != nil {
+		log.Fatalf("%s: %v", *tournamentsPath, err)
+	}
+
+	db, err := sql.Open("mysql", config.Load().DSN())
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewMySQLRepository(db)
+
+	for _, path := range flag.Args() {
+		games, skipped, err := readGames(path, players, tournaments)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		imported, err := repo.ImportReferenceGames(games)
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		fmt.Printf("%s: imported %d games, skipped %d\n", path, imported, skipped)
+	}
+}
+
+func readNames(path string, read func(io.Reader) ([]string, error)) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+func readGames(path string, players, tournaments []string) ([]model.ReferenceGame, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	h, records, err := wthor.ReadGames(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	source := filepath.Base(path)
+	var games []model.ReferenceGame
+	skipped := 0
+	for i, g := range records {
+		if err := wthor.Verify(g); err != nil {
+			log.Printf("%s: skipping game %d: %v", path, i+1, err)
+			skipped++
+			continue
+		}
+		games = append(games, model.ReferenceGame{
+			Source:           source,
+			SourceIndex:      i + 1,
+			Year:             h.Year,
+			Tournament:       name(tournaments, g.Tournament),
+			BlackPlayer:      name(players, g.Black),
+			WhitePlayer:      name(players, g.White),
+			BlackScore:       g.BlackScore,
+			TheoreticalScore: g.TheoreticalScore,
+			Transcript:       g.Moves,
+		})
+	}
+	return games, skipped, nil
+}
+
+// name returns names[i], or nil when the names were not given or i is out of
+// range.
+func name(names []string, i int) *string {
+	if i < 0 || i >= len(names) {
+		return nil
+	}
+	return &names[i]
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 type Config struct {
@@ -11,15 +12,29 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+	// OpeningBook is the path of the opening book file; empty uses the built-in book.
+	OpeningBook string
+	// AbandonAfter is how long a game in progress may sit idle before it is
+	// abandoned; zero disables the cleanup job.
+	AbandonAfter time.Duration
+	// AbandonedRetention is how long abandoned games are kept before they are
+	// cleaned up according to AbandonedCleanup, "archive" or "purge"; zero
+	// keeps them.
+	AbandonedRetention time.Duration
+	AbandonedCleanup   string
 }
 
 func Load() *Config {
 	return &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "3306"),
-		DBUser:     getEnv("DB_USER", "root"),
-		DBPassword: getEnv("DB_PASSWORD", "rootpassword"),
-		DBName:     getEnv("DB_NAME", "othello"),
+		DBHost:             getEnv("DB_HOST", "localhost"),
+		DBPort:             getEnv("DB_PORT", "3306"),
+		DBUser:             getEnv("DB_USER", "root"),
+		DBPassword:         getEnv("DB_PASSWORD", "rootpassword"),
+		DBName:             getEnv("DB_NAME", "othello"),
+		OpeningBook:        os.Getenv("OPENING_BOOK"),
+		AbandonAfter:       getDuration("ABANDON_AFTER", 24*time.Hour),
+		AbandonedRetention: getDuration("ABANDONED_RETENTION", 0),
+		AbandonedCleanup:   getEnv("ABANDONED_CLEANUP", "archive"),
 	}
 }
 
@@ -34,6 +49,16 @@ func (c *Config) DSN() string {
 		c.DBUser, c.DBPassword, c.DBHost, c.DBPort, c.DBName)
 }
 
+// getDuration reads a duration such as "72h"; unset or invalid values fall
+// back to defaultVal.
+func getDuration(key string, defaultVal time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultVal
+	}
+	return d
+}
+
 func getEnv(key, defaultVal string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -53,3 +54,23 @@ func TestDSN(t *testing.T) {
 		t.Fatalf("expected DSN %s, got %s", expected, dsn)
 	}
 }
+
+func TestLoadCleanup(t *testing.T) {
+	cfg := Load()
+	if cfg.AbandonAfter != 24*time.Hour || cfg.AbandonedRetention != 0 || cfg.AbandonedCleanup != "archive" {
+		t.Fatalf("expected 24h, no retention and archive, got %v, %v and %s", cfg.AbandonAfter, cfg.AbandonedRetention, cfg.AbandonedCleanup)
+	}
+
+	os.Setenv("ABANDON_AFTER", "30m")
+	os.Setenv("ABANDONED_RETENTION", "invalid")
+	defer os.Unsetenv("ABANDON_AFTER")
+	defer os.Unsetenv("ABANDONED_RETENTION")
+
+	cfg = Load()
+	if cfg.AbandonAfter != 30*time.Minute {
+		t.Fatalf("expected AbandonAfter 30m, got %v", cfg.AbandonAfter)
+	}
+	if cfg.AbandonedRetention != 0 {
+		t.Fatalf("expected an invalid retention to fall back to 0, got %v", cfg.AbandonedRetention)
+	}
+}
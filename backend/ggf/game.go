@@ -0,0 +1,149 @@
+package ggf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/transcript"
+)
+
+// forfeitScore is the score written for games won by resignation or on time.
+const forfeitScore = 64
+
+// FromGame builds the record of a stored game. Per-move time controls and
+// games ended without a winner, such as aborted ones, have no GGF equivalent
+// and are written without TI or RE.
+func FromGame(game *model.Game, moves []model.Move) *Record {
+	rec := &Record{Date: game.CreatedAt, BlackRating: game.BlackRating, WhiteRating: game.WhiteRating}
+	if game.PlayedAt != nil {
+		rec.Date = *game.PlayedAt
+	}
+	if game.StartPosition != nil {
+		rec.Position = *game.StartPosition
+	}
+	if game.BlackPlayer != nil {
+		rec.Black = *game.BlackPlayer
+	}
+	if game.WhitePlayer != nil {
+		rec.White = *game.WhitePlayer
+	}
+	if game.ClockBaseMS != nil {
+		rec.Clock = &Clock{Base: time.Duration(*game.ClockBaseMS) * time.Millisecond}
+		if game.ClockIncrementMS != nil {
+			rec.Clock.Increment = time.Duration(*game.ClockIncrementMS) * time.Millisecond
+		}
+	}
+	if game.Result != nil && game.Termination != nil {
+		rec.Result = result(game)
+	}
+	for _, m := range moves {
+		color, _ := board.ParseColor(m.Color)
+		rec.Moves = append(rec.Moves, transcript.Move{Color: color, Col: m.Col, Row: m.Row, Pass: m.Pass})
+	}
+	return rec
+}
+
+func result(game *model.Game) *Result {
+	var sign float64
+	switch *game.Result {
+	case "black_win":
+		sign = 1
+	case "white_win":
+		sign = -1
+	case "draw":
+	default:
+		return nil
+	}
+
+	switch *game.Termination {
+	case model.TerminationNormal:
+		if game.BlackCount == nil || game.WhiteCount == nil {
+			return nil
+		}
+		return &Result{Score: float64(*game.BlackCount - *game.WhiteCount)}
+	case model.TerminationResignation, model.TerminationAbandoned:
+		return &Result{Score: sign * forfeitScore, Ending: 'r'}
+	case model.TerminationTimeout:
+		return &Result{Score: sign * forfeitScore, Ending: 't'}
+	case model.TerminationAgreement:
+		return &Result{Score: 0, Ending: 's'}
+	}
+	return nil
+}
+
+// Game builds the rows of an imported game by replaying its record. The
+// caller sets the play ID, and the host secret of a game still in progress.
+// A result without an ending on a position that is not final is taken as
+// agreed by the players.
+func (rec *Record) Game() (*model.Game, []model.Move, error) {
+	tm, g, err := rec.Replay()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	game := &model.Game{BlackRating: rec.BlackRating, WhiteRating: rec.WhiteRating}
+	if rec.Black != "" {
+		game.BlackPlayer = &rec.Black
+	}
+	if rec.White != "" {
+		game.WhitePlayer = &rec.White
+	}
+	if !rec.Date.IsZero() {
+		game.PlayedAt = &rec.Date
+	}
+	if rec.Position != "" {
+		game.StartPosition = &rec.Position
+	}
+	if c := rec.Clock; c != nil && c.Base > 0 {
+		base, increment := int(c.Base.Milliseconds()), int(c.Increment.Milliseconds())
+		game.ClockBaseMS = &base
+		if increment > 0 {
+			game.ClockIncrementMS = &increment
+		}
+	}
+
+	blackCount, whiteCount := g.Board.Count(board.Black), g.Board.Count(board.White)
+	var res, termination string
+	switch {
+	case rec.Result != nil:
+		res, termination = recordResult(rec.Result, g.IsOver())
+		if termination == model.TerminationNormal && res != board.Result(blackCount, whiteCount) {
+			return nil, nil, fmt.Errorf("result %+.3f does not match the final position %d-%d", rec.Result.Score, blackCount, whiteCount)
+		}
+	case g.IsOver():
+		res, termination = board.Result(blackCount, whiteCount), model.TerminationNormal
+	}
+	if res != "" {
+		game.BlackCount, game.WhiteCount = &blackCount, &whiteCount
+		game.Result, game.Termination = &res, &termination
+	}
+
+	moves := make([]model.Move, len(tm))
+	for i, m := range tm {
+		moves[i] = model.Move{Color: m.Color.String(), Col: m.Col, Row: m.Row, MoveOrder: i + 1, Pass: m.Pass}
+	}
+	return game, moves, nil
+}
+
+func recordResult(r *Result, over bool) (string, string) {
+	res := "draw"
+	if r.Score > 0 {
+		res = "black_win"
+	} else if r.Score < 0 {
+		res = "white_win"
+	}
+	switch r.Ending {
+	case 'r':
+		return res, model.TerminationResignation
+	case 't':
+		return res, model.TerminationTimeout
+	case 's':
+		return res, model.TerminationAgreement
+	}
+	if !over {
+		return res, model.TerminationAgreement
+	}
+	return res, model.TerminationNormal
+}
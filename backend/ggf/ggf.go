@@ -0,0 +1,454 @@
+// Package ggf reads and writes Othello games in the Generic Game Format used
+// by the game server archives, such as
+//
+//	(;GM[Othello]PC[GGS/os]DT[2003.12.15_13:24:03.UTC]PB[alice]PW[bob]RB[2197.01]RW[2199.72]TI[15:00//02:00]TY[8]RE[+18.000]BO[8 -------- -------- -------- ---O*--- ---*O--- -------- -------- -------- *]B[f5//0.01]W[d6]...;)
+//
+// Only 8x8 games are supported.
+package ggf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/transcript"
+)
+
+// dateLayout is the DT format written by the game servers; dates given as
+// Unix seconds are read too.
+const dateLayout = "2006.01.02_15:04:05.MST"
+
+// Record is a game record. Moves hold explicit passes where the record has
+// them; Replay inserts the others.
+type Record struct {
+	Place                    string
+	Date                     time.Time
+	Black, White             string
+	BlackRating, WhiteRating *float64
+	Clock                    *Clock
+	// Position is the board.ParsePosition string the game starts from, empty
+	// for the standard setup.
+	Position string
+	// Result is nil for a game in progress.
+	Result *Result
+	Moves  []transcript.Move
+}
+
+// Clock is the TI time control. Extension is extra time granted once the
+// main time is used up; it is kept but not played.
+type Clock struct {
+	Base, Increment, Extension time.Duration
+}
+
+// Result is the RE property: the disc difference from black's point of view
+// and how the game ended.
+type Result struct {
+	Score float64
+	// Ending is 0 for a game played out, or 'r' for resignation, 't' for
+	// time and 's' for a score agreed by the players.
+	Ending byte
+}
+
+// Reader reads the records of a GGF file, which usually holds one game per line.
+type Reader struct {
+	r *bufio.Reader
+	n int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Parse reads a single record.
+func Parse(s string) (*Record, error) {
+	rec, err := NewReader(strings.NewReader(s)).Next()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("no game record found")
+	}
+	return rec, err
+}
+
+// Next returns the next record, or io.EOF after the last one. After a
+// malformed record, reading resumes at the start of the following one.
+func (rd *Reader) Next() (*Record, error) {
+	if err := rd.skipToRecord(); err != nil {
+		return nil, err
+	}
+	rd.n++
+	rec, err := rd.record()
+	if err != nil {
+		return nil, fmt.Errorf("game %d: %w", rd.n, err)
+	}
+	return rec, nil
+}
+
+func (rd *Reader) skipToRecord() error {
+	for {
+		b, err := rd.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != '(' {
+			continue
+		}
+		if b, err = rd.r.ReadByte(); err != nil {
+			return err
+		}
+		if b == ';' {
+			return nil
+		}
+		rd.r.UnreadByte()
+	}
+}
+
+func (rd *Reader) record() (*Record, error) {
+	rec := &Record{}
+	for {
+		b, err := rd.nonSpace()
+		if err != nil {
+			return nil, unexpected(err)
+		}
+		if b == ';' {
+			if b, err = rd.r.ReadByte(); err != nil {
+				return nil, unexpected(err)
+			}
+			if b != ')' {
+				return nil, fmt.Errorf("unexpected %q after ';'", b)
+			}
+			return rec, nil
+		}
+
+		key := []byte{b}
+		for {
+			if b, err = rd.r.ReadByte(); err != nil {
+				return nil, unexpected(err)
+			}
+			if b == '[' {
+				break
+			}
+			key = append(key, b)
+		}
+		value, err := rd.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := rec.set(strings.TrimSpace(string(key)), value); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (rd *Reader) nonSpace() (byte, error) {
+	for {
+		b, err := rd.r.ReadByte()
+		if err != nil || (b != ' ' && b != '\t' && b != '\r' && b != '\n') {
+			return b, err
+		}
+	}
+}
+
+// value reads a property value up to its closing bracket; a backslash
+// escapes the next character.
+func (rd *Reader) value() (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := rd.r.ReadByte()
+		if err != nil {
+			return "", unexpected(err)
+		}
+		switch b {
+		case ']':
+			return sb.String(), nil
+		case '\\':
+			if b, err = rd.r.ReadByte(); err != nil {
+				return "", unexpected(err)
+			}
+		}
+		sb.WriteByte(b)
+	}
+}
+
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (rec *Record) set(key, value string) error {
+	switch key {
+	case "GM":
+		if !strings.EqualFold(value, "othello") {
+			return fmt.Errorf("unsupported game %q", value)
+		}
+	case "TY":
+		if strings.TrimSpace(value) != "8" {
+			return fmt.Errorf("unsupported game type %q", value)
+		}
+	case "BO":
+		position, err := parseBoard(value)
+		if err != nil {
+			return err
+		}
+		rec.Position = position
+	case "PC":
+		rec.Place = value
+	case "DT":
+		rec.Date, _ = parseDate(value)
+	case "PB":
+		rec.Black = value
+	case "PW":
+		rec.White = value
+	case "RB", "RW":
+		rating, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("malformed rating %q", value)
+		}
+		if key == "RB" {
+			rec.BlackRating = &rating
+		} else {
+			rec.WhiteRating = &rating
+		}
+	case "TI":
+		clock, err := parseClock(value)
+		if err != nil {
+			return err
+		}
+		rec.Clock = clock
+	case "RE":
+		result, err := parseResult(value)
+		if err != nil {
+			return err
+		}
+		rec.Result = result
+	case "B", "W":
+		m, err := parseMove(key, value)
+		if err != nil {
+			return err
+		}
+		rec.Moves = append(rec.Moves, m)
+	}
+	// other properties, such as komi or comments, are ignored
+	return nil
+}
+
+// parseBoard reads a BO property: the board size, the squares with * for
+// black and O for white, and the side to move.
+func parseBoard(v string) (string, error) {
+	fields := strings.Fields(v)
+	if len(fields) < 3 || fields[0] != "8" {
+		return "", fmt.Errorf("malformed board %q", v)
+	}
+	position := strings.ReplaceAll(strings.Join(fields[1:], ""), "*", "X")
+	g, err := board.ParsePosition(position)
+	if err != nil {
+		return "", fmt.Errorf("malformed board: %w", err)
+	}
+	if position = board.FormatPosition(g); position == board.StartPosition {
+		return "", nil
+	}
+	return position, nil
+}
+
+// formatBoard writes a position string as a BO property.
+func formatBoard(position string) string {
+	if position == "" {
+		position = board.StartPosition
+	}
+	position = strings.ReplaceAll(position, "X", "*")
+	var sb strings.Builder
+	sb.WriteString("8")
+	for row := 0; row < board.Size; row++ {
+		sb.WriteString(" " + position[row*board.Size:(row+1)*board.Size])
+	}
+	sb.WriteString(" " + position[board.Size*board.Size:])
+	return sb.String()
+}
+
+func parseDate(v string) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), true
+	}
+	t, err := time.Parse(dateLayout, v)
+	return t, err == nil
+}
+
+// parseClock reads "base/increment/extension" where each part is [[h:]m:]s
+// and may be empty.
+func parseClock(v string) (*Clock, error) {
+	parts := strings.Split(v, "/")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("malformed time control %q", v)
+	}
+	var ds [3]time.Duration
+	for i, p := range parts {
+		d, err := parseDuration(p)
+		if err != nil {
+			return nil, fmt.Errorf("malformed time control %q", v)
+		}
+		ds[i] = d
+	}
+	return &Clock{Base: ds[0], Increment: ds[1], Extension: ds[2]}, nil
+}
+
+func parseDuration(v string) (time.Duration, error) {
+	// servers append options such as ",N" to the times
+	v, _, _ = strings.Cut(strings.TrimSpace(v), ",")
+	if v == "" {
+		return 0, nil
+	}
+	var secs float64
+	for _, f := range strings.Split(v, ":") {
+		x, err := strconv.ParseFloat(f, 64)
+		if err != nil || x < 0 {
+			return 0, errors.New("malformed duration")
+		}
+		secs = secs*60 + x
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+func parseResult(v string) (*Result, error) {
+	score, ending, _ := strings.Cut(strings.TrimSpace(v), ":")
+	s, err := strconv.ParseFloat(score, 64)
+	if err != nil || len(ending) > 1 || (ending != "" && !strings.Contains("rts", ending)) {
+		return nil, fmt.Errorf("malformed result %q", v)
+	}
+	r := &Result{Score: s}
+	if ending != "" {
+		r.Ending = ending[0]
+	}
+	return r, nil
+}
+
+// parseMove reads a move such as "d3//0.01": the square or PA for a pass,
+// optionally followed by an evaluation and the time taken.
+func parseMove(key, v string) (transcript.Move, error) {
+	color := board.Black
+	if key == "W" {
+		color = board.White
+	}
+	sq, _, _ := strings.Cut(strings.TrimSpace(v), "/")
+	sq = strings.ToLower(sq)
+	if sq == "pa" {
+		return transcript.Move{Color: color, Col: -1, Row: -1, Pass: true}, nil
+	}
+	p, ok := board.ParseSquare(sq)
+	if !ok {
+		return transcript.Move{}, fmt.Errorf("malformed move %s[%s]", key, v)
+	}
+	return transcript.Move{Color: color, Col: p.Col, Row: p.Row}, nil
+}
+
+// Replay plays the moves from the starting position, checking them against
+// the rules and inserting the passes the record leaves out. It returns the
+// moves with every pass and the resulting game.
+func (rec *Record) Replay() ([]transcript.Move, *board.Game, error) {
+	g := board.NewGame()
+	if rec.Position != "" {
+		var err error
+		if g, err = board.ParsePosition(rec.Position); err != nil {
+			return nil, nil, err
+		}
+	}
+	var moves []transcript.Move
+	for i, m := range rec.Moves {
+		if g.IsOver() {
+			return nil, nil, fmt.Errorf("move %d: the game is already over", i+1)
+		}
+		if !m.Pass && m.Color != g.Turn && g.MustPass() {
+			moves = append(moves, transcript.Move{Color: g.Turn, Col: -1, Row: -1, Pass: true})
+			g.Pass(g.Turn)
+		}
+		var err error
+		if m.Pass {
+			err = g.Pass(m.Color)
+		} else {
+			err = g.Play(m.Color, m.Col, m.Row)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("move %d (%s): %w", i+1, moveString(m), err)
+		}
+		moves = append(moves, m)
+	}
+	if g.MustPass() {
+		moves = append(moves, transcript.Move{Color: g.Turn, Col: -1, Row: -1, Pass: true})
+		g.Pass(g.Turn)
+	}
+	return moves, g, nil
+}
+
+// Format writes a record on a single line.
+func Format(rec *Record) string {
+	var sb strings.Builder
+	sb.WriteString("(;GM[Othello]")
+	prop := func(key, value string) {
+		if value != "" {
+			sb.WriteString(key + "[" + escape(value) + "]")
+		}
+	}
+	prop("PC", rec.Place)
+	if !rec.Date.IsZero() {
+		prop("DT", rec.Date.UTC().Format(dateLayout))
+	}
+	prop("PB", rec.Black)
+	prop("PW", rec.White)
+	if rec.BlackRating != nil {
+		prop("RB", strconv.FormatFloat(*rec.BlackRating, 'f', 2, 64))
+	}
+	if rec.WhiteRating != nil {
+		prop("RW", strconv.FormatFloat(*rec.WhiteRating, 'f', 2, 64))
+	}
+	if c := rec.Clock; c != nil {
+		prop("TI", strings.TrimRight(formatDuration(c.Base)+"/"+formatDuration(c.Increment)+"/"+formatDuration(c.Extension), "/"))
+	}
+	prop("TY", "8")
+	if r := rec.Result; r != nil {
+		score := fmt.Sprintf("%+.3f", r.Score)
+		if r.Score == 0 {
+			score = "0.000"
+		}
+		if r.Ending != 0 {
+			score += ":" + string(r.Ending)
+		}
+		prop("RE", score)
+	}
+	prop("BO", formatBoard(rec.Position))
+	for _, m := range rec.Moves {
+		key := "B"
+		if m.Color == board.White {
+			key = "W"
+		}
+		prop(key, moveString(m))
+	}
+	sb.WriteString(";)")
+	return sb.String()
+}
+
+func moveString(m transcript.Move) string {
+	if m.Pass {
+		return "PA"
+	}
+	return board.Position{Col: m.Col, Row: m.Row}.String()
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	secs := int(d.Round(time.Second) / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+}
+
+func escape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `]`, `\]`).Replace(v)
+}
@@ -0,0 +1,251 @@
+package ggf
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/transcript"
+)
+
+// passGame is a full game in which black has to pass after the eighth move;
+// white wins 23-41.
+const passGame = "d3c3f5d2d1e1b2c1a3a1d6c7b3c6g6e3f4g3f6g5e6e7g4h6e8d8f7c2h2e2f3h3b8h4f8d7c5c4g7a4h5b6b4h1b5f2g2b7a8f1a2a6g1a5b1g8c8h8h7a7"
+
+// ggfMoves writes the moves of a transcript as GGF properties, leaving out
+// the passes.
+func ggfMoves(t *testing.T, s string) string {
+	t.Helper()
+	moves, _, err := transcript.Parse(s)
+	if err != nil {
+		t.Fatalf("invalid transcript: %v", err)
+	}
+	var sb strings.Builder
+	for _, m := range moves {
+		if m.Pass {
+			continue
+		}
+		key := "B"
+		if m.Color == board.White {
+			key = "W"
+		}
+		sb.WriteString(key + "[" + strings.ToUpper(board.Position{Col: m.Col, Row: m.Row}.String()) + "//1.5]")
+	}
+	return sb.String()
+}
+
+func TestParse(t *testing.T) {
+	rec, err := Parse("(;GM[Othello]PC[GGS/os]DT[2003.12.15_13:24:03.UTC]PB[alice]PW[bob]RB[2197.01]RW[2199.72]TI[15:00//02:00]TY[8]RE[+64.000:r]" +
+		"BO[8 -------- -------- -------- ---O*--- ---*O--- -------- -------- -------- *]B[f5//0.01]W[d6]B[c3]W[d3]B[c4];)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Place != "GGS/os" || rec.Black != "alice" || rec.White != "bob" || rec.Position != "" {
+		t.Fatalf("expected GGS/os alice-bob, got %+v", rec)
+	}
+	if rec.BlackRating == nil || *rec.BlackRating != 2197.01 || rec.WhiteRating == nil || *rec.WhiteRating != 2199.72 {
+		t.Fatalf("expected the ratings, got %v and %v", rec.BlackRating, rec.WhiteRating)
+	}
+	if want := time.Date(2003, 12, 15, 13, 24, 3, 0, time.UTC); !rec.Date.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, rec.Date)
+	}
+	if rec.Clock == nil || rec.Clock.Base != 15*time.Minute || rec.Clock.Increment != 0 || rec.Clock.Extension != 2*time.Minute {
+		t.Fatalf("expected 15:00//02:00, got %+v", rec.Clock)
+	}
+	if rec.Result == nil || rec.Result.Score != 64 || rec.Result.Ending != 'r' {
+		t.Fatalf("expected +64 by resignation, got %+v", rec.Result)
+	}
+	if got := transcript.Format(rec.Moves); got != "f5d6c3d3c4" {
+		t.Fatalf("expected f5d6c3d3c4, got %s", got)
+	}
+
+	game, moves, err := rec.Game()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *game.Result != "black_win" || *game.Termination != model.TerminationResignation {
+		t.Fatalf("expected black_win by resignation, got %s by %s", *game.Result, *game.Termination)
+	}
+	if *game.ClockBaseMS != 900000 || game.ClockIncrementMS != nil {
+		t.Fatalf("expected a 15 minute clock, got %v+%v", *game.ClockBaseMS, game.ClockIncrementMS)
+	}
+	if *game.BlackPlayer != "alice" || game.PlayedAt == nil {
+		t.Fatalf("expected alice and the date, got %+v", game)
+	}
+	if len(moves) != 5 || moves[4].MoveOrder != 5 || moves[4].Color != "black" {
+		t.Fatalf("expected black's fifth move, got %+v", moves)
+	}
+}
+
+func TestGame_InsertsPasses(t *testing.T) {
+	rec, err := Parse("(;GM[Othello]" + ggfMoves(t, passGame) + ";)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	game, moves, err := rec.Game()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !moves[8].Pass || moves[8].Color != "black" || moves[8].MoveOrder != 9 {
+		t.Fatalf("expected black to pass as move 9, got %+v", moves[8])
+	}
+	if *game.Result != "white_win" || *game.Termination != model.TerminationNormal || *game.BlackCount != 23 || *game.WhiteCount != 41 {
+		t.Fatalf("expected white_win 23-41, got %s %d-%d", *game.Result, *game.BlackCount, *game.WhiteCount)
+	}
+
+	// an explicit pass is accepted as well
+	moveProps := ggfMoves(t, passGame)
+	cut := strings.Index(moveProps, "W[C1//1.5]") + len("W[C1//1.5]")
+	if _, _, err := mustParse(t, "(;GM[Othello]"+moveProps[:cut]+"B[PA]"+moveProps[cut:]+";)").Replay(); err != nil {
+		t.Fatalf("unexpected error with an explicit pass: %v", err)
+	}
+}
+
+func mustParse(t *testing.T, s string) *Record {
+	t.Helper()
+	rec, err := Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return rec
+}
+
+func TestGame_Errors(t *testing.T) {
+	tests := []struct {
+		record, want string
+	}{
+		{"(;GM[Othello]RE[+4.000]" + ggfMoves(t, passGame) + ";)", "does not match the final position"},
+		{"(;GM[Othello]B[f5]B[d6];)", "move 2 (d6)"},
+		{"(;GM[Othello]B[PA];)", "move 1 (PA)"},
+	}
+	for _, tt := range tests {
+		_, _, err := mustParse(t, tt.record).Game()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("expected error containing %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestParse_Unsupported(t *testing.T) {
+	for _, record := range []string{
+		"(;GM[Go];)",
+		"(;GM[Othello]TY[8r];)",
+		"(;GM[Othello]BO[8 -------- -------- -------- ---O*--- ---*O--- -------- -------- *];)",
+		"(;GM[Othello]BO[10 -------- -------- -------- ---O*--- ---*O--- -------- -------- -------- *];)",
+		"(;GM[Othello]B[z9];)",
+		"(;GM[Othello]RE[black];)",
+		"(;GM[Othello]B[f5]",
+		"no record",
+	} {
+		if _, err := Parse(record); err == nil {
+			t.Fatalf("expected an error for %s", record)
+		}
+	}
+}
+
+func TestReader_SkipsMalformedRecords(t *testing.T) {
+	rd := NewReader(strings.NewReader("(;GM[Othello]B[f5];)\n(;GM[Othello]B[z9];)\n(;GM[Othello]PB[a\\]b]B[f5]W[d6];)\n"))
+
+	rec, err := rd.Next()
+	if err != nil || len(rec.Moves) != 1 {
+		t.Fatalf("expected a first game with one move, got %+v, %v", rec, err)
+	}
+	if _, err := rd.Next(); err == nil || !strings.Contains(err.Error(), "game 2") {
+		t.Fatalf("expected an error for game 2, got %v", err)
+	}
+	rec, err = rd.Next()
+	if err != nil || len(rec.Moves) != 2 || rec.Black != "a]b" {
+		t.Fatalf("expected a third game by a]b with two moves, got %+v, %v", rec, err)
+	}
+	if _, err := rd.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestFormat_RoundTrip(t *testing.T) {
+	tm, _, err := transcript.Parse(passGame)
+	if err != nil {
+		t.Fatalf("invalid transcript: %v", err)
+	}
+	moves := make([]model.Move, len(tm))
+	for i, m := range tm {
+		moves[i] = model.Move{Color: m.Color.String(), Col: m.Col, Row: m.Row, MoveOrder: i + 1, Pass: m.Pass}
+	}
+	player, rating, base, increment := "carol]", 1800.5, 300000, 2000
+	result, termination, blackCount, whiteCount := "white_win", model.TerminationNormal, 23, 41
+	game := &model.Game{
+		BlackPlayer: &player, BlackRating: &rating,
+		ClockBaseMS: &base, ClockIncrementMS: &increment,
+		Result: &result, Termination: &termination, BlackCount: &blackCount, WhiteCount: &whiteCount,
+		CreatedAt: time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+	}
+
+	s := Format(FromGame(game, moves))
+	if !strings.Contains(s, "TI[05:00/00:02]") || !strings.Contains(s, "RE[-18.000]") || !strings.Contains(s, "B[PA]") {
+		t.Fatalf("expected the clock, result and pass in %s", s)
+	}
+
+	rec := mustParse(t, s)
+	imported, importedMoves, err := rec.Game()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *imported.BlackPlayer != player || *imported.BlackRating != rating || !imported.PlayedAt.Equal(game.CreatedAt) {
+		t.Fatalf("expected the players and date back, got %+v", imported)
+	}
+	if *imported.ClockBaseMS != base || *imported.ClockIncrementMS != increment {
+		t.Fatalf("expected 300000+2000, got %d+%d", *imported.ClockBaseMS, *imported.ClockIncrementMS)
+	}
+	if *imported.Result != result || len(importedMoves) != len(moves) {
+		t.Fatalf("expected %s in %d moves, got %s in %d", result, len(moves), *imported.Result, len(importedMoves))
+	}
+}
+
+func TestFromGame_Endings(t *testing.T) {
+	tests := []struct {
+		result, termination, want string
+	}{
+		{"black_win", model.TerminationResignation, "RE[+64.000:r]"},
+		{"white_win", model.TerminationTimeout, "RE[-64.000:t]"},
+		{"draw", model.TerminationAgreement, "RE[0.000:s]"},
+		{"aborted", model.TerminationAborted, ""},
+	}
+	for _, tt := range tests {
+		game := &model.Game{Result: &tt.result, Termination: &tt.termination}
+		s := Format(FromGame(game, nil))
+		if tt.want == "" && strings.Contains(s, "RE[") {
+			t.Fatalf("%s: expected no result, got %s", tt.termination, s)
+		}
+		if !strings.Contains(s, tt.want) {
+			t.Fatalf("%s: expected %s, got %s", tt.termination, tt.want, s)
+		}
+	}
+}
+
+func TestParse_StartPosition(t *testing.T) {
+	// white to move, with only the h1 corner to take
+	rec := mustParse(t, "(;GM[Othello]BO[8 O******- -------- -------- -------- -------- -------- -------- -------- O]W[h1];)")
+	if want := "OXXXXXX-" + strings.Repeat("-", 56) + "O"; rec.Position != want {
+		t.Fatalf("expected the position with white to move, got %s", rec.Position)
+	}
+
+	game, moves, err := rec.Game()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if game.StartPosition == nil || *game.StartPosition != rec.Position {
+		t.Fatalf("expected the start position on the game, got %v", game.StartPosition)
+	}
+	if len(moves) != 1 || moves[0].Color != "white" || *game.Result != "white_win" || *game.WhiteCount != 8 {
+		t.Fatalf("expected white to take the rank and win, got %+v and %+v", moves, game)
+	}
+
+	if s := Format(FromGame(game, moves)); !strings.Contains(s, "BO[8 O******- -------- -------- -------- -------- -------- -------- -------- O]") {
+		t.Fatalf("expected the start position to be written back, got %s", s)
+	}
+}
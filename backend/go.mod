@@ -7,3 +7,5 @@ require github.com/go-sql-driver/mysql v1.8.1
 require filippo.io/edwards25519 v1.1.0 // indirect
 
 require github.com/google/uuid v1.6.0
+
+require github.com/gorilla/websocket v1.5.3
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/model"
+)
+
+// CleanupPolicy configures SweepAbandoned.
+type CleanupPolicy struct {
+	// InactiveAfter is how long a game in progress may go without a move or
+	// any other update before it is abandoned.
+	InactiveAfter time.Duration
+	// RetainFor is how long abandoned games are kept; zero keeps them forever.
+	// With Archive they are moved to the archive tables instead of deleted.
+	RetainFor time.Duration
+	Archive   bool
+}
+
+// SweepAbandoned ends, every interval until ctx is done, the games nobody has
+// played in for p.InactiveAfter, then cleans up old abandoned games.
+func (h *Handler) SweepAbandoned(ctx context.Context, interval time.Duration, p CleanupPolicy) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := h.sweepAbandoned(time.Now(), p); err != nil {
+				log.Printf("abandoned game sweep: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) sweepAbandoned(now time.Time, p CleanupPolicy) error {
+	games, err := h.repo.GetInactiveGames(p.InactiveAfter)
+	if err != nil {
+		return err
+	}
+	for i := range games {
+		playID := games[i].PlayID
+		moves, err := h.repo.GetMovesAfter(playID, 0)
+		if err != nil {
+			return err
+		}
+		// a move may have come in since the games were listed
+		if n := len(moves); n > 0 && now.Sub(moves[n-1].CreatedAt) < p.InactiveAfter {
+			continue
+		}
+		g, err := replayGame(&games[i], moves)
+		if err != nil {
+			return fmt.Errorf("%s: %w", playID, err)
+		}
+		// a conflict means the game ended in the meantime
+		if status, msg := h.finishGame(&games[i], abandonedResult(&games[i], g, len(moves)), model.TerminationAbandoned); status != http.StatusOK && status != http.StatusConflict {
+			return fmt.Errorf("%s: %s", playID, msg)
+		}
+	}
+
+	if p.RetainFor <= 0 {
+		return nil
+	}
+	n, err := h.repo.PurgeAbandonedGames(p.RetainFor, p.Archive)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("cleaned up %d abandoned games", n)
+	}
+	return nil
+}
+
+// abandonedResult decides a game nobody plays in anymore. Once both players
+// have moved, the player who stopped moving loses; a game that never really
+// started has no winner.
+func abandonedResult(game *model.Game, g *board.Game, moveCount int) string {
+	if moveCount < abortMoveLimit || g.IsOver() || (game.GuestSecret == nil && game.AILevel == nil) {
+		return "abandoned"
+	}
+	return winFor(g.Turn.Opponent())
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dog-nose/othello-backend/model"
+)
+
+// inactiveRepository lists a single inactive game with the given guest and
+// moves, recording how it ends.
+func inactiveRepository(guestSecret *string, moves []model.Move, result, termination *string) *mockRepository {
+	hostSecret := "host-secret"
+	game := model.Game{PlayID: "game-123", HostSecret: &hostSecret, GuestSecret: guestSecret}
+	return &mockRepository{
+		getInactiveGamesFn: func(idle time.Duration) ([]model.Game, error) {
+			return []model.Game{game}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return movesAfter(moves, afterMoveOrder), nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, r, tm string) error {
+			*result, *termination = r, tm
+			return nil
+		},
+	}
+}
+
+func playedMoves(at time.Time) []model.Move {
+	return []model.Move{
+		{PlayID: "game-123", Color: "black", Col: 2, Row: 3, MoveOrder: 1, CreatedAt: at},
+		{PlayID: "game-123", Color: "white", Col: 2, Row: 2, MoveOrder: 2, CreatedAt: at},
+	}
+}
+
+func TestSweepAbandoned_AwardsWin(t *testing.T) {
+	var result, termination string
+	guestSecret := "guest-secret"
+	repo := inactiveRepository(&guestSecret, playedMoves(time.Now().Add(-2*time.Hour)), &result, &termination)
+	var purged, archived bool
+	repo.purgeAbandonedFn = func(age time.Duration, archive bool) (int64, error) {
+		purged, archived = true, archive
+		return 1, nil
+	}
+	h := New(repo)
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	if err := h.sweepAbandoned(time.Now(), CleanupPolicy{InactiveAfter: time.Hour, RetainFor: 24 * time.Hour, Archive: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// black stopped moving
+	if result != "white_win" || termination != model.TerminationAbandoned {
+		t.Fatalf("expected white_win by abandonment, got %s by %s", result, termination)
+	}
+	if ev := <-sub.Events(); ev.Type != model.EventGameOver {
+		t.Fatalf("expected game_over, got %s", ev.Type)
+	}
+	if !purged || !archived {
+		t.Fatal("expected old abandoned games to be archived")
+	}
+}
+
+func TestSweepAbandoned_NoGuest(t *testing.T) {
+	var result, termination string
+	repo := inactiveRepository(nil, nil, &result, &termination)
+	repo.purgeAbandonedFn = func(age time.Duration, archive bool) (int64, error) {
+		t.Fatal("expected no cleanup without a retention")
+		return 0, nil
+	}
+	h := New(repo)
+
+	if err := h.sweepAbandoned(time.Now(), CleanupPolicy{InactiveAfter: time.Hour}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "abandoned" || termination != model.TerminationAbandoned {
+		t.Fatalf("expected abandoned without a winner, got %s by %s", result, termination)
+	}
+}
+
+func TestSweepAbandoned_RecentMove(t *testing.T) {
+	var result, termination string
+	guestSecret := "guest-secret"
+	h := New(inactiveRepository(&guestSecret, playedMoves(time.Now().Add(-time.Minute)), &result, &termination))
+
+	if err := h.sweepAbandoned(time.Now(), CleanupPolicy{InactiveAfter: time.Hour}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "" {
+		t.Fatalf("expected the game to go on, got %s", result)
+	}
+}
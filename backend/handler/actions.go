@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/repository"
+)
+
+// abortMoveLimit is the number of recorded moves after which a game can no
+// longer be aborted, only resigned.
+const abortMoveLimit = 2
+
+// EndGame resigns the game for the player owning the secret; the opponent wins.
+func (h *Handler) EndGame(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.playerAction(w, r)
+	if !ok {
+		return
+	}
+
+	if status, msg := h.finishGame(game, winFor(color.Opponent()), model.TerminationResignation); status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+func (h *Handler) OfferDraw(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.playerAction(w, r)
+	if !ok {
+		return
+	}
+	if game.GuestSecret == nil {
+		respondError(w, http.StatusConflict, "there is no human opponent to offer a draw to")
+		return
+	}
+	if game.DrawOffer != nil {
+		if *game.DrawOffer == color.String() {
+			respondError(w, http.StatusConflict, "draw already offered")
+			return
+		}
+		respondError(w, http.StatusConflict, "opponent has already offered a draw")
+		return
+	}
+
+	offer := color.String()
+	if err := h.repo.SetDrawOffer(game.PlayID, &offer); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to offer draw")
+		return
+	}
+	h.hub.Publish(model.GameEvent{Type: model.EventDrawOffer, PlayID: game.PlayID, Color: offer})
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+func (h *Handler) AcceptDraw(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.playerAction(w, r)
+	if !ok {
+		return
+	}
+	if !offeredBy(game, color.Opponent()) {
+		respondError(w, http.StatusConflict, "no draw offer to accept")
+		return
+	}
+
+	if status, msg := h.finishGame(game, "draw", model.TerminationAgreement); status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+func (h *Handler) DeclineDraw(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.playerAction(w, r)
+	if !ok {
+		return
+	}
+	if !offeredBy(game, color.Opponent()) {
+		respondError(w, http.StatusConflict, "no draw offer to decline")
+		return
+	}
+
+	if err := h.repo.SetDrawOffer(game.PlayID, nil); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to decline draw")
+		return
+	}
+	h.hub.Publish(model.GameEvent{Type: model.EventDrawDecline, PlayID: game.PlayID, Color: color.String()})
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+// AbortGame cancels a game that has barely started. Aborted games have no
+// winner.
+func (h *Handler) AbortGame(w http.ResponseWriter, r *http.Request) {
+	game, _, ok := h.playerAction(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := h.repo.GetMoveCount(game.PlayID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get move count")
+		return
+	}
+	if count >= abortMoveLimit {
+		respondError(w, http.StatusConflict, fmt.Sprintf("games can only be aborted within the first %d moves", abortMoveLimit))
+		return
+	}
+
+	if status, msg := h.finishGame(game, "aborted", model.TerminationAborted); status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+func (h *Handler) RequestTakeback(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.playerAction(w, r)
+	if !ok {
+		return
+	}
+	if game.GuestSecret == nil {
+		respondError(w, http.StatusConflict, "there is no human opponent to ask for a takeback")
+		return
+	}
+	if game.TakebackRequest != nil {
+		if *game.TakebackRequest == color.String() {
+			respondError(w, http.StatusConflict, "takeback already requested")
+			return
+		}
+		respondError(w, http.StatusConflict, "opponent has already requested a takeback")
+		return
+	}
+	moves, err := h.repo.GetMovesAfter(game.PlayID, 0)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get moves")
+		return
+	}
+	if _, ok := takebackFrom(moves, color); !ok {
+		respondError(w, http.StatusConflict, "no move to take back")
+		return
+	}
+
+	request := color.String()
+	if err := h.repo.SetTakebackRequest(game.PlayID, &request); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to request takeback")
+		return
+	}
+	h.hub.Publish(model.GameEvent{Type: model.EventTakebackRequest, PlayID: game.PlayID, Color: request})
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+// AcceptTakeback undoes the requester's last move together with everything
+// recorded after it.
+func (h *Handler) AcceptTakeback(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.playerAction(w, r)
+	if !ok {
+		return
+	}
+	requester := color.Opponent()
+	if game.TakebackRequest == nil || *game.TakebackRequest != requester.String() {
+		respondError(w, http.StatusConflict, "no takeback request to accept")
+		return
+	}
+	moves, err := h.repo.GetMovesAfter(game.PlayID, 0)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get moves")
+		return
+	}
+	from, ok := takebackFrom(moves, requester)
+	if !ok {
+		respondError(w, http.StatusConflict, "no move to take back")
+		return
+	}
+
+	if err := h.repo.TakeBack(game.PlayID, from, requester.String()); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to take back")
+		return
+	}
+	after, takebacks := from-1, game.Takebacks+1
+	h.hub.Publish(model.GameEvent{Type: model.EventTakeback, PlayID: game.PlayID, Color: requester.String(), AfterMoveOrder: &after, Takebacks: &takebacks})
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+func (h *Handler) DeclineTakeback(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.playerAction(w, r)
+	if !ok {
+		return
+	}
+	if game.TakebackRequest == nil || *game.TakebackRequest != color.Opponent().String() {
+		respondError(w, http.StatusConflict, "no takeback request to decline")
+		return
+	}
+
+	if err := h.repo.SetTakebackRequest(game.PlayID, nil); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to decline takeback")
+		return
+	}
+	h.hub.Publish(model.GameEvent{Type: model.EventTakebackDecline, PlayID: game.PlayID, Color: color.String()})
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+// takebackFrom returns the move_order of the last stone placed by c.
+func takebackFrom(moves []model.Move, c board.Color) (int, bool) {
+	for i := len(moves) - 1; i >= 0; i-- {
+		if !moves[i].Pass && moves[i].Color == c.String() {
+			return moves[i].MoveOrder, true
+		}
+	}
+	return 0, false
+}
+
+// playerAction decodes a game action and authenticates its sender as a player
+// of a game still in progress. It writes the error response itself.
+func (h *Handler) playerAction(w http.ResponseWriter, r *http.Request) (*model.Game, board.Color, bool) {
+	return h.authorizePlayer(w, r, false)
+}
+
+// authorizePlayer is playerAction for a game that is over, or still in
+// progress, as selected by over.
+func (h *Handler) authorizePlayer(w http.ResponseWriter, r *http.Request, over bool) (*model.Game, board.Color, bool) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil, board.Empty, false
+	}
+
+	var req model.GameActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return nil, board.Empty, false
+	}
+
+	if req.PlayID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return nil, board.Empty, false
+	}
+
+	game, err := h.repo.GetGame(req.PlayID)
+	if err != nil {
+		if errors.Is(err, repository.ErrGameNotFound) {
+			respondError(w, http.StatusNotFound, "game not found")
+			return nil, board.Empty, false
+		}
+		respondError(w, http.StatusInternalServerError, "failed to get game")
+		return nil, board.Empty, false
+	}
+	if over && game.Result == nil {
+		respondError(w, http.StatusConflict, "game is still in progress")
+		return nil, board.Empty, false
+	}
+	if !over && game.Result != nil {
+		respondError(w, http.StatusConflict, "game is already over")
+		return nil, board.Empty, false
+	}
+
+	color, ok := playerColor(game, req.Secret)
+	if !ok {
+		respondError(w, http.StatusForbidden, "invalid secret")
+		return nil, board.Empty, false
+	}
+	return game, color, true
+}
+
+// playerColor returns the color played by the owner of secret; the host
+// always plays black.
+func playerColor(game *model.Game, secret string) (board.Color, bool) {
+	switch {
+	case game.HostSecret != nil && secret == *game.HostSecret:
+		return board.Black, true
+	case game.GuestSecret != nil && secret == *game.GuestSecret:
+		return board.White, true
+	}
+	return board.Empty, false
+}
+
+func winFor(c board.Color) string {
+	if c == board.Black {
+		return "black_win"
+	}
+	return "white_win"
+}
+
+func offeredBy(game *model.Game, c board.Color) bool {
+	return game.DrawOffer != nil && *game.DrawOffer == c.String()
+}
+
+// finishGame ends a game at its current position with the given outcome and
+// tells subscribers. On failure it returns the status and message to reply with.
+func (h *Handler) finishGame(game *model.Game, result, termination string) (int, string) {
+	playID := game.PlayID
+	moves, err := h.repo.GetMovesAfter(playID, 0)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to get moves"
+	}
+	g, err := replayGame(game, moves)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to replay moves"
+	}
+
+	blackCount, whiteCount := g.Board.Count(board.Black), g.Board.Count(board.White)
+	if err := h.repo.EndGame(playID, blackCount, whiteCount, result, termination); err != nil {
+		if errors.Is(err, repository.ErrGameAlreadyEnded) {
+			return http.StatusConflict, "game is already over"
+		}
+		return http.StatusInternalServerError, "failed to end game"
+	}
+	h.publishGameOver(playID, blackCount, whiteCount, result, termination)
+	return http.StatusOK, ""
+}
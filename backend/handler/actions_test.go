@@ -0,0 +1,425 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/model"
+)
+
+// actionRepository returns a PvP game with the given pending draw offer,
+// recording what the handlers write.
+type actionRepository struct {
+	*mockRepository
+	result, termination string
+	ended, offerSet     bool
+	offer               *string
+}
+
+func newActionRepository(drawOffer *string, moves []model.Move) *actionRepository {
+	hostSecret, guestSecret := "host-secret", "guest-secret"
+	ar := &actionRepository{}
+	ar.mockRepository = &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret, DrawOffer: drawOffer}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+		getMoveCountFn: func(playID string) (int, error) {
+			return len(moves), nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, result, termination string) error {
+			ar.ended, ar.result, ar.termination = true, result, termination
+			return nil
+		},
+		setDrawOfferFn: func(playID string, color *string) error {
+			ar.offerSet, ar.offer = true, color
+			return nil
+		},
+	}
+	return ar
+}
+
+func gameAction(h http.HandlerFunc, secret string) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(model.GameActionRequest{PlayID: "game-123", Secret: secret})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestEndGame_RecordsResignation(t *testing.T) {
+	repo := newActionRepository(nil, nil)
+	h := New(repo)
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	rec := gameAction(h.EndGame, "guest-secret")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if repo.result != "black_win" || repo.termination != model.TerminationResignation {
+		t.Fatalf("expected black_win by resignation, got %s by %s", repo.result, repo.termination)
+	}
+	ev := <-sub.Events()
+	if ev.Type != model.EventGameOver || ev.Termination == nil || *ev.Termination != model.TerminationResignation {
+		t.Fatalf("expected game_over by resignation, got %+v", ev)
+	}
+}
+
+func TestOfferDraw(t *testing.T) {
+	repo := newActionRepository(nil, nil)
+	h := New(repo)
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	rec := gameAction(h.OfferDraw, "host-secret")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if repo.offer == nil || *repo.offer != "black" {
+		t.Fatalf("expected a draw offer from black, got %v", repo.offer)
+	}
+	ev := <-sub.Events()
+	if ev.Type != model.EventDrawOffer || ev.Color != "black" {
+		t.Fatalf("expected draw_offered by black, got %+v", ev)
+	}
+}
+
+func TestOfferDraw_AlreadyOffered(t *testing.T) {
+	black := "black"
+	for _, secret := range []string{"host-secret", "guest-secret"} {
+		repo := newActionRepository(&black, nil)
+		h := New(repo)
+
+		rec := gameAction(h.OfferDraw, secret)
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", rec.Code)
+		}
+		if repo.offerSet {
+			t.Fatal("expected SetDrawOffer not to be called")
+		}
+	}
+}
+
+func TestOfferDraw_AIGame(t *testing.T) {
+	hostSecret, level := "host-secret", 3
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, AILevel: &level}, nil
+		},
+	}
+	h := New(mock)
+
+	rec := gameAction(h.OfferDraw, hostSecret)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestAcceptDraw(t *testing.T) {
+	black := "black"
+	repo := newActionRepository(&black, passGameMoves("game-123"))
+	h := New(repo)
+
+	rec := gameAction(h.AcceptDraw, "guest-secret")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if repo.result != "draw" || repo.termination != model.TerminationAgreement {
+		t.Fatalf("expected a draw by agreement, got %s by %s", repo.result, repo.termination)
+	}
+}
+
+func TestAcceptDraw_OwnOffer(t *testing.T) {
+	black := "black"
+	repo := newActionRepository(&black, nil)
+	h := New(repo)
+
+	rec := gameAction(h.AcceptDraw, "host-secret")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+	if repo.ended {
+		t.Fatal("expected EndGame not to be called")
+	}
+}
+
+func TestAcceptDraw_NoOffer(t *testing.T) {
+	repo := newActionRepository(nil, nil)
+	h := New(repo)
+
+	rec := gameAction(h.AcceptDraw, "guest-secret")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestDeclineDraw(t *testing.T) {
+	white := "white"
+	repo := newActionRepository(&white, nil)
+	h := New(repo)
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	rec := gameAction(h.DeclineDraw, "host-secret")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !repo.offerSet || repo.offer != nil {
+		t.Fatalf("expected the offer to be cleared, got %v", repo.offer)
+	}
+	ev := <-sub.Events()
+	if ev.Type != model.EventDrawDecline || ev.Color != "black" {
+		t.Fatalf("expected draw_declined by black, got %+v", ev)
+	}
+}
+
+func TestPlaceStone_DeclinesDrawOffer(t *testing.T) {
+	white := "white"
+	repo := newActionRepository(&white, nil)
+	h := New(repo)
+
+	body := model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 3, Secret: "host-secret"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !repo.offerSet || repo.offer != nil {
+		t.Fatalf("expected the offer to be cleared, got %v", repo.offer)
+	}
+}
+
+func TestAbortGame(t *testing.T) {
+	repo := newActionRepository(nil, []model.Move{{PlayID: "game-123", Color: "black", Col: 2, Row: 3, MoveOrder: 1}})
+	h := New(repo)
+
+	rec := gameAction(h.AbortGame, "guest-secret")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if repo.result != "aborted" || repo.termination != model.TerminationAborted {
+		t.Fatalf("expected an aborted game, got %s by %s", repo.result, repo.termination)
+	}
+}
+
+func TestAbortGame_TooLate(t *testing.T) {
+	repo := newActionRepository(nil, passGameMoves("game-123")[:abortMoveLimit])
+	h := New(repo)
+
+	rec := gameAction(h.AbortGame, "host-secret")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+	if repo.ended {
+		t.Fatal("expected EndGame not to be called")
+	}
+}
+
+func TestGameActions_InvalidSecret(t *testing.T) {
+	h := New(newActionRepository(nil, nil))
+	for _, action := range []http.HandlerFunc{h.EndGame, h.OfferDraw, h.AcceptDraw, h.DeclineDraw, h.AbortGame} {
+		if rec := gameAction(action, "wrong"); rec.Code != http.StatusForbidden {
+			t.Fatalf("expected status 403, got %d", rec.Code)
+		}
+	}
+}
+
+// takebackRepository is an actionRepository whose game has the given pending
+// takeback request and records takebacks.
+func takebackRepository(request *string, moves []model.Move) (*actionRepository, *int) {
+	ar := newActionRepository(nil, moves)
+	getGame := ar.getGameFn
+	ar.getGameFn = func(playID string) (*model.Game, error) {
+		game, err := getGame(playID)
+		game.TakebackRequest = request
+		return game, err
+	}
+	from := new(int)
+	ar.setTakebackRequestFn = func(playID string, color *string) error {
+		ar.offerSet, ar.offer = true, color
+		return nil
+	}
+	ar.takeBackFn = func(playID string, fromMoveOrder int, requestedBy string) error {
+		*from = fromMoveOrder
+		return nil
+	}
+	return ar, from
+}
+
+func TestRequestTakeback(t *testing.T) {
+	repo, _ := takebackRepository(nil, passGameMoves("game-123")[:3])
+	h := New(repo)
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	rec := gameAction(h.RequestTakeback, "host-secret")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if repo.offer == nil || *repo.offer != "black" {
+		t.Fatalf("expected a takeback request from black, got %v", repo.offer)
+	}
+	ev := <-sub.Events()
+	if ev.Type != model.EventTakebackRequest || ev.Color != "black" {
+		t.Fatalf("expected takeback_requested by black, got %+v", ev)
+	}
+}
+
+func TestRequestTakeback_NoMove(t *testing.T) {
+	repo, _ := takebackRepository(nil, passGameMoves("game-123")[:1])
+	h := New(repo)
+
+	rec := gameAction(h.RequestTakeback, "guest-secret")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestAcceptTakeback(t *testing.T) {
+	white := "white"
+	// white asks to undo move 2 after black has already replied with move 3
+	repo, from := takebackRepository(&white, passGameMoves("game-123")[:3])
+	h := New(repo)
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	rec := gameAction(h.AcceptTakeback, "host-secret")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if *from != 2 {
+		t.Fatalf("expected moves from 2 to be taken back, got %d", *from)
+	}
+	ev := <-sub.Events()
+	if ev.Type != model.EventTakeback || ev.AfterMoveOrder == nil || *ev.AfterMoveOrder != 1 {
+		t.Fatalf("expected takeback to move 1, got %+v", ev)
+	}
+}
+
+func TestAcceptTakeback_OwnRequest(t *testing.T) {
+	white := "white"
+	repo, from := takebackRepository(&white, passGameMoves("game-123")[:3])
+	h := New(repo)
+
+	rec := gameAction(h.AcceptTakeback, "guest-secret")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+	if *from != 0 {
+		t.Fatal("expected TakeBack not to be called")
+	}
+}
+
+func TestDeclineTakeback(t *testing.T) {
+	black := "black"
+	repo, _ := takebackRepository(&black, passGameMoves("game-123")[:1])
+	h := New(repo)
+
+	rec := gameAction(h.DeclineTakeback, "guest-secret")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !repo.offerSet || repo.offer != nil {
+		t.Fatalf("expected the request to be cleared, got %v", repo.offer)
+	}
+}
+
+func TestPollMoves_AfterTakeback(t *testing.T) {
+	// the client saw moves up to 5, then 4 and 5 were taken back
+	repo, _ := takebackRepository(nil, passGameMoves("game-123")[:3])
+	repo.getFirstRevertedFn = func(playID string, upTo, sinceTakebacks int) (int, bool, error) {
+		return 4, true, nil
+	}
+	h := New(repo)
+
+	rec := httptest.NewRecorder()
+	h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123", AfterMoveOrder: 5}))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.PollMovesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.AfterMoveOrder == nil || *resp.AfterMoveOrder != 3 {
+		t.Fatalf("expected the cursor to be reset to 3, got %v", resp.AfterMoveOrder)
+	}
+	if len(resp.Moves) != 0 {
+		t.Fatalf("expected no moves after 3, got %d", len(resp.Moves))
+	}
+}
+
+func TestPollMoves_ReplayedAfterTakeback(t *testing.T) {
+	// the client saw moves up to 5, then 4 and 5 were taken back and played again
+	repo, _ := takebackRepository(nil, passGameMoves("game-123")[:5])
+	getGame := repo.getGameFn
+	repo.getGameFn = func(playID string) (*model.Game, error) {
+		game, err := getGame(playID)
+		game.Takebacks = 1
+		return game, err
+	}
+	repo.getFirstRevertedFn = func(playID string, upTo, sinceTakebacks int) (int, bool, error) {
+		return 4, sinceTakebacks < 1, nil
+	}
+	h := New(repo)
+
+	poll := func(takebacks *int) model.PollMovesResponse {
+		rec := httptest.NewRecorder()
+		h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123", AfterMoveOrder: 5, Takebacks: takebacks}))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		var resp model.PollMovesResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	zero, one := 0, 1
+	resp := poll(&zero)
+	if resp.AfterMoveOrder == nil || *resp.AfterMoveOrder != 3 {
+		t.Fatalf("expected the cursor to be reset to 3, got %v", resp.AfterMoveOrder)
+	}
+	if len(resp.Moves) != 2 || resp.Moves[0].MoveOrder != 4 {
+		t.Fatalf("expected moves 4 and 5 again, got %+v", resp.Moves)
+	}
+	if resp.Takebacks != 1 {
+		t.Fatalf("expected 1 takeback, got %d", resp.Takebacks)
+	}
+
+	// a client that has caught up is left alone
+	if resp := poll(&one); resp.AfterMoveOrder != nil || len(resp.Moves) != 0 {
+		t.Fatalf("expected nothing new, got %+v", resp)
+	}
+	// as is one that leaves the count out
+	if resp := poll(nil); resp.AfterMoveOrder != nil || len(resp.Moves) != 0 {
+		t.Fatalf("expected no rewind without a takebacks count, got %+v", resp)
+	}
+}
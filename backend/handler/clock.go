@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/clock"
+	"github.com/dog-nose/othello-backend/model"
+)
+
+// maxClockMS bounds every time control setting to a day.
+const maxClockMS = 24 * 60 * 60 * 1000
+
+// validateTimeControl checks a time control requested at /start-game.
+func validateTimeControl(tc *model.TimeControl) error {
+	for _, v := range []int{tc.BaseMS, tc.IncrementMS, tc.MoveMS} {
+		if v < 0 || v > maxClockMS {
+			return fmt.Errorf("time_control values must be between 0 and %d ms", maxClockMS)
+		}
+	}
+	if tc.MoveMS > 0 {
+		if tc.BaseMS > 0 || tc.IncrementMS > 0 {
+			return errors.New("move_ms cannot be combined with base_ms or increment_ms")
+		}
+		return nil
+	}
+	if tc.BaseMS == 0 {
+		return errors.New("time_control needs base_ms or move_ms")
+	}
+	return nil
+}
+
+// timeControl returns the time control of a game, or nil if it is untimed.
+func timeControl(game *model.Game) *model.TimeControl {
+	if game.ClockBaseMS == nil && game.ClockMoveMS == nil {
+		return nil
+	}
+	tc := &model.TimeControl{}
+	if game.ClockBaseMS != nil {
+		tc.BaseMS = *game.ClockBaseMS
+	}
+	if game.ClockIncrementMS != nil {
+		tc.IncrementMS = *game.ClockIncrementMS
+	}
+	if game.ClockMoveMS != nil {
+		tc.MoveMS = *game.ClockMoveMS
+	}
+	return tc
+}
+
+// clockState runs the clocks of a timed game over its recorded moves, using
+// the time each row was written and restarting them after a takeback. The
+// clocks stay stopped until the guest has joined, so nobody loses on time to
+// an empty seat, and then start for the side to move.
+func clockState(game *model.Game, moves []model.Move) clock.State {
+	tc := timeControl(game)
+	if tc == nil {
+		return clock.State{}
+	}
+	c := clock.Control{
+		Base:      time.Duration(tc.BaseMS) * time.Millisecond,
+		Increment: time.Duration(tc.IncrementMS) * time.Millisecond,
+		PerMove:   time.Duration(tc.MoveMS) * time.Millisecond,
+	}
+	if game.GuestSecret == nil {
+		return clock.Replay(c, nil)
+	}
+	cm := make([]clock.Move, 0, len(moves))
+	for _, m := range moves {
+		color, _ := board.ParseColor(m.Color)
+		cm = append(cm, clock.Move{Color: color, At: m.CreatedAt})
+	}
+	first := board.Black
+	if g, err := startingGame(game); err == nil {
+		first = g.Turn
+	}
+	var resumed time.Time
+	if game.ClockResumedAt != nil {
+		resumed = *game.ClockResumedAt
+	}
+	return clock.ReplayResumed(c, first, cm, resumed)
+}
+
+func clockResponse(s clock.State, now time.Time) *model.Clock {
+	c := &model.Clock{
+		BlackMS: s.Left(board.Black, now).Milliseconds(),
+		WhiteMS: s.Left(board.White, now).Milliseconds(),
+	}
+	if s.Running() != board.Empty {
+		c.Running = s.Running().String()
+	}
+	return c
+}
+
+// flag ends a game lost on time by the side whose clock ran out.
+func (h *Handler) flag(game *model.Game, loser board.Color) (int, string) {
+	return h.finishGame(game, winFor(loser.Opponent()), model.TerminationTimeout)
+}
+
+// SweepClocks ends, every interval until ctx is done, the timed games whose
+// player to move ran out of time without trying to move again.
+func (h *Handler) SweepClocks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := h.sweepClocks(time.Now()); err != nil {
+				log.Printf("clock sweep: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) sweepClocks(now time.Time) error {
+	games, err := h.repo.GetTimedGames()
+	if err != nil {
+		return err
+	}
+	for i := range games {
+		playID := games[i].PlayID
+		moves, err := h.repo.GetMovesAfter(playID, 0)
+		if err != nil {
+			return err
+		}
+		s := clockState(&games[i], moves)
+		if !s.Flagged(now) {
+			continue
+		}
+		// a conflict means the game ended in the meantime
+		if status, msg := h.flag(&games[i], s.Running()); status != http.StatusOK && status != http.StatusConflict {
+			return fmt.Errorf("%s: %s", playID, msg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dog-nose/othello-backend/model"
+)
+
+// timedRepository returns a PvP game on a one minute clock in which white's
+// reply was made ago, recording how the game ends.
+func timedRepository(ago time.Duration, result, termination *string) *mockRepository {
+	hostSecret, guestSecret, baseMS := "host-secret", "guest-secret", 60000
+	now := time.Now()
+	moves := []model.Move{
+		{PlayID: "game-123", Color: "black", Col: 2, Row: 3, MoveOrder: 1, CreatedAt: now.Add(-ago - time.Second)},
+		{PlayID: "game-123", Color: "white", Col: 2, Row: 2, MoveOrder: 2, CreatedAt: now.Add(-ago)},
+	}
+	return &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret, ClockBaseMS: &baseMS}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return movesAfter(moves, afterMoveOrder), nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, r, tm string) error {
+			*result, *termination = r, tm
+			return nil
+		},
+	}
+}
+
+func movesAfter(moves []model.Move, afterMoveOrder int) []model.Move {
+	out := []model.Move{}
+	for _, m := range moves {
+		if m.MoveOrder > afterMoveOrder {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func timedMove(h *Handler) *httptest.ResponseRecorder {
+	body := model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 1, Secret: "host-secret"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+	h.PlaceStone(rec, req)
+	return rec
+}
+
+func TestStartGame_TimeControl(t *testing.T) {
+	var base, inc, move int
+	mock := &mockRepository{
+		setTimeControlFn: func(playID string, baseMS, incrementMS, moveMS int) error {
+			base, inc, move = baseMS, incrementMS, moveMS
+			return nil
+		},
+	}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodPost, "/start-game", strings.NewReader(`{"time_control":{"base_ms":300000,"increment_ms":3000}}`))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if base != 300000 || inc != 3000 || move != 0 {
+		t.Fatalf("expected 300000+3000, got %d+%d (move %d)", base, inc, move)
+	}
+}
+
+func TestStartGame_InvalidTimeControl(t *testing.T) {
+	bodies := []string{
+		`{"time_control":{}}`,
+		`{"time_control":{"increment_ms":1000}}`,
+		`{"time_control":{"base_ms":60000,"move_ms":10000}}`,
+		`{"time_control":{"base_ms":-1}}`,
+		`{"time_control":{"move_ms":86400001}}`,
+		`{"opponent":"ai","level":3,"time_control":{"base_ms":60000}}`,
+	}
+	for _, body := range bodies {
+		mock := &mockRepository{
+			createGameWithSecretFn: func(playID, hostSecret string) error {
+				t.Fatalf("expected no game to be created for %s", body)
+				return nil
+			},
+		}
+		h := New(mock)
+
+		req := httptest.NewRequest(http.MethodPost, "/start-game", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.StartGame(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestPlaceStone_InTime(t *testing.T) {
+	var result, termination string
+	h := New(timedRepository(30*time.Second, &result, &termination))
+
+	rec := timedMove(h)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if result != "" {
+		t.Fatalf("expected the game to go on, got %s", result)
+	}
+}
+
+func TestPlaceStone_FlagFall(t *testing.T) {
+	var result, termination string
+	h := New(timedRepository(2*time.Minute, &result, &termination))
+
+	rec := timedMove(h)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+	if result != "white_win" || termination != model.TerminationTimeout {
+		t.Fatalf("expected white_win on time, got %s by %s", result, termination)
+	}
+}
+
+func TestGetGame_Clock(t *testing.T) {
+	var result, termination string
+	h := New(timedRepository(30*time.Second, &result, &termination))
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.GameStateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.TimeControl == nil || resp.TimeControl.BaseMS != 60000 {
+		t.Fatalf("expected a 60000ms time control, got %+v", resp.TimeControl)
+	}
+	if resp.Clock == nil || resp.Clock.Running != "black" {
+		t.Fatalf("expected black's clock to be running, got %+v", resp.Clock)
+	}
+	if resp.Clock.WhiteMS != 59000 {
+		t.Fatalf("expected white to have 59000ms, got %d", resp.Clock.WhiteMS)
+	}
+	if resp.Clock.BlackMS > 30000 || resp.Clock.BlackMS < 29000 {
+		t.Fatalf("expected black to have about 30000ms, got %d", resp.Clock.BlackMS)
+	}
+}
+
+func TestSweepClocks(t *testing.T) {
+	var result, termination string
+	stalled := timedRepository(2*time.Minute, &result, &termination)
+	stalled.getTimedGamesFn = func() ([]model.Game, error) {
+		game, _ := stalled.GetGame("game-123")
+		return []model.Game{*game}, nil
+	}
+	h := New(stalled)
+
+	if err := h.sweepClocks(time.Now().Add(-90 * time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "" {
+		t.Fatalf("expected black to still have time 90s ago, got %s", result)
+	}
+
+	if err := h.sweepClocks(time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "white_win" || termination != model.TerminationTimeout {
+		t.Fatalf("expected white_win on time, got %s by %s", result, termination)
+	}
+}
+
+func TestPlaceStone_TimedWaitsForGuest(t *testing.T) {
+	var result, termination string
+	mock := timedRepository(30*time.Second, &result, &termination)
+	getGame := mock.getGameFn
+	mock.getGameFn = func(playID string) (*model.Game, error) {
+		game, err := getGame(playID)
+		game.GuestSecret = nil
+		return game, err
+	}
+	mock.getMovesAfterFn = func(playID string, afterMoveOrder int) ([]model.Move, error) {
+		return []model.Move{}, nil
+	}
+	mock.recordMoveFn = func(playID, color string, col, row, moveOrder int) error {
+		t.Fatal("expected no move to be recorded")
+		return nil
+	}
+	h := New(mock)
+
+	body := model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 3, Secret: "host-secret"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestSweepClocks_NoGuest(t *testing.T) {
+	var result, termination string
+	// the host moved long ago and nobody ever joined
+	mock := timedRepository(2*time.Minute, &result, &termination)
+	moves, _ := mock.GetMovesAfter("game-123", 0)
+	mock.getMovesAfterFn = func(playID string, afterMoveOrder int) ([]model.Move, error) {
+		return movesAfter(moves[:1], afterMoveOrder), nil
+	}
+	mock.getTimedGamesFn = func() ([]model.Game, error) {
+		game, _ := mock.GetGame("game-123")
+		game.GuestSecret = nil
+		return []model.Game{*game}, nil
+	}
+	h := New(mock)
+
+	if err := h.sweepClocks(time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "" {
+		t.Fatalf("expected the clock to wait for the guest, got %s", result)
+	}
+}
+
+func TestPlaceStone_AfterTakeback(t *testing.T) {
+	var result, termination string
+	// black thought for two minutes before the move now taken back
+	mock := timedRepository(2*time.Minute, &result, &termination)
+	resumed := time.Now().Add(-10 * time.Second)
+	getGame := mock.getGameFn
+	mock.getGameFn = func(playID string) (*model.Game, error) {
+		game, err := getGame(playID)
+		game.ClockResumedAt = &resumed
+		return game, err
+	}
+	h := New(mock)
+
+	rec := timedMove(h)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if result != "" {
+		t.Fatalf("expected the requester not to lose on time, got %s by %s", result, termination)
+	}
+}
+
+func TestSweepClocks_StalledFirstMove(t *testing.T) {
+	var result, termination string
+	// the guest joined two minutes ago and black never moved
+	mock := timedRepository(0, &result, &termination)
+	joined := time.Now().Add(-2 * time.Minute)
+	mock.getMovesAfterFn = func(playID string, afterMoveOrder int) ([]model.Move, error) {
+		return []model.Move{}, nil
+	}
+	mock.getTimedGamesFn = func() ([]model.Game, error) {
+		game, _ := mock.GetGame("game-123")
+		game.ClockResumedAt = &joined
+		return []model.Game{*game}, nil
+	}
+	h := New(mock)
+
+	if err := h.sweepClocks(joined.Add(30 * time.Second)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "" {
+		t.Fatalf("expected black to still have time 30s after the join, got %s", result)
+	}
+
+	if err := h.sweepClocks(time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "white_win" || termination != model.TerminationTimeout {
+		t.Fatalf("expected white_win on time, got %s by %s", result, termination)
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dog-nose/othello-backend/ggf"
+	"github.com/dog-nose/othello-backend/model"
+)
+
+// GetGGF exports a game as a GGF record for other Othello tools.
+func (h *Handler) GetGGF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	playID := r.PathValue("play_id")
+	if playID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+
+	game, status, msg := h.watchGame(playID, r.URL.Query().Get("token"))
+	if status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+	moves, err := h.repo.GetMovesAfter(playID, 0)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get moves")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, model.GGFResponse{PlayID: playID, GGF: ggf.Format(ggf.FromGame(game, moves))})
+}
@@ -1,22 +1,74 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 
+	"github.com/dog-nose/othello-backend/ai"
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/book"
+	"github.com/dog-nose/othello-backend/hub"
 	"github.com/dog-nose/othello-backend/model"
 	"github.com/dog-nose/othello-backend/repository"
 )
 
+// analysisTimeout bounds the work a single endgame analysis request may do.
+const analysisTimeout = 30 * time.Second
+
+// aiMoveTimeout bounds the search for one AI reply. It does not depend on the
+// request, so a client hanging up cannot cut the search short.
+const aiMoveTimeout = 30 * time.Second
+
+// Hint tuning: the default search budget, the depth cap of the heuristic
+// search and the number of empties at which hints become exact.
+const (
+	defaultHintTimeLimit = 2 * time.Second
+	hintMaxDepth         = 20
+	hintSolveEmpties     = 14
+)
+
+// Upper bounds for the per-game MCTS budget overrides.
+const (
+	maxPlayouts      = 1000000
+	maxAITimeLimitMS = 30000
+)
+
+// Long-polling limits for /poll-moves.
+const (
+	maxPollWaitMS  = 30000
+	maxPollWaiters = 1000
+)
+
+// maxChatLength is the longest chat message accepted, in characters.
+const maxChatLength = 500
+
+// aiColor is the side played by the server in AI games; the host is always black.
+const aiColor = board.White
+
 type Handler struct {
 	repo repository.Repository
+	book *book.Book
+	hub  *hub.Hub
+	// waiters is a semaphore bounding concurrent long-poll requests.
+	waiters chan struct{}
 }
 
 func New(repo repository.Repository) *Handler {
-	return &Handler{repo: repo}
+	return &Handler{repo: repo, book: book.Default(), hub: hub.New(), waiters: make(chan struct{}, maxPollWaiters)}
+}
+
+// SetBook replaces the built-in opening book.
+func (h *Handler) SetBook(bk *book.Book) {
+	h.book = bk
 }
 
 func (h *Handler) StartGame(w http.ResponseWriter, r *http.Request) {
@@ -25,13 +77,96 @@ func (h *Handler) StartGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The body is optional: without one a game between two humans is created
+	var req model.StartGameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var start *board.Game
+	if req.Position != "" {
+		g, err := board.ParsePosition(req.Position)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid position: "+err.Error())
+			return
+		}
+		if g.IsOver() || g.MustPass() {
+			respondError(w, http.StatusBadRequest, "position must leave the side to move a legal move")
+			return
+		}
+		start = g
+	}
+
 	playID := uuid.New().String()
 	hostSecret := uuid.New().String()
-	if err := h.repo.CreateGameWithSecret(playID, hostSecret); err != nil {
+	var err error
+	switch req.Opponent {
+	case "", "human":
+		if req.TimeControl != nil {
+			if err := validateTimeControl(req.TimeControl); err != nil {
+				respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		err = h.repo.CreateGameWithSecret(playID, hostSecret)
+		if err == nil && req.TimeControl != nil {
+			err = h.repo.SetTimeControl(playID, req.TimeControl.BaseMS, req.TimeControl.IncrementMS, req.TimeControl.MoveMS)
+		}
+	case "ai":
+		if req.TimeControl != nil {
+			respondError(w, http.StatusBadRequest, "time_control is only available for games between humans")
+			return
+		}
+		if !ai.ValidLevel(req.Level) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("level must be between %d and %d", ai.MinLevel, ai.MaxLevel))
+			return
+		}
+		engine := req.Engine
+		if engine == "" {
+			engine = ai.EngineAlphaBeta
+		}
+		if !ai.ValidEngine(engine) {
+			respondError(w, http.StatusBadRequest, "engine must be 'alphabeta' or 'mcts'")
+			return
+		}
+		if req.Playouts < 0 || req.Playouts > maxPlayouts {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("playouts must be between 0 and %d", maxPlayouts))
+			return
+		}
+		if req.TimeLimitMS < 0 || req.TimeLimitMS > maxAITimeLimitMS {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("time_limit_ms must be between 0 and %d", maxAITimeLimitMS))
+			return
+		}
+		err = h.repo.CreateAIGame(playID, hostSecret, engine, req.Level, req.Playouts, req.TimeLimitMS)
+	default:
+		respondError(w, http.StatusBadRequest, "opponent must be 'human' or 'ai'")
+		return
+	}
+	if err == nil && req.Private {
+		err = h.repo.SetPrivate(playID)
+	}
+	if err == nil && start != nil {
+		err = h.repo.SetStartPosition(playID, board.FormatPosition(start))
+	}
+	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to create game")
 		return
 	}
 
+	// the AI opens positions with white to move
+	if start != nil && req.Opponent == "ai" && start.Turn == aiColor {
+		game, err := h.repo.GetGame(playID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to get game")
+			return
+		}
+		if err := h.advance(playID, game, start, 1); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
 	respondJSON(w, http.StatusOK, model.StartGameResponse{PlayID: playID, HostSecret: hostSecret})
 }
 
@@ -51,7 +186,8 @@ func (h *Handler) PlaceStone(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "play_id is required")
 		return
 	}
-	if req.Color != "black" && req.Color != "white" {
+	color, ok := board.ParseColor(req.Color)
+	if !ok {
 		respondError(w, http.StatusBadRequest, "color must be 'black' or 'white'")
 		return
 	}
@@ -60,32 +196,54 @@ func (h *Handler) PlaceStone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	moveCount, err := h.repo.GetMoveCount(req.PlayID)
+	game, err := h.repo.GetGame(req.PlayID)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to get move count")
+		respondError(w, http.StatusInternalServerError, "failed to get game")
+		return
+	}
+	if game.Result != nil {
+		respondError(w, http.StatusConflict, "game is already over")
 		return
 	}
 
-	moveOrder := moveCount + 1
-
-	// Secret validation for PvP games
-	game, err := h.repo.GetGame(req.PlayID)
+	// Rebuild the game from the recorded history; the side to move comes from
+	// the board itself, not from move_order parity.
+	moves, err := h.repo.GetMovesAfter(req.PlayID, 0)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to get game")
+		respondError(w, http.StatusInternalServerError, "failed to get moves")
+		return
+	}
+	g, err := replayGame(game, moves)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to replay moves")
+		return
+	}
+	if g.IsOver() {
+		respondError(w, http.StatusConflict, "game is already over")
+		return
+	}
+	if color != g.Turn {
+		respondError(w, http.StatusConflict, "not your turn: it is "+g.Turn.String()+"'s turn")
+		return
+	}
+	if game.AILevel != nil && color == aiColor {
+		respondError(w, http.StatusForbidden, aiColor.String()+" is played by the AI")
+		return
+	}
+
+	// the clocks only start once both players are here
+	if timeControl(game) != nil && game.GuestSecret == nil {
+		respondError(w, http.StatusConflict, "timed games start once the guest has joined")
 		return
 	}
 
 	if game.HostSecret != nil {
-		// PvP game: validate secret
+		// PvP game: black is the host, white is the guest
 		var expectedSecret string
-		if moveOrder%2 == 1 {
-			// Odd move_order = black (host)
+		if g.Turn == board.Black {
 			expectedSecret = *game.HostSecret
-		} else {
-			// Even move_order = white (guest)
-			if game.GuestSecret != nil {
-				expectedSecret = *game.GuestSecret
-			}
+		} else if game.GuestSecret != nil {
+			expectedSecret = *game.GuestSecret
 		}
 		if req.Secret != expectedSecret {
 			respondError(w, http.StatusForbidden, "invalid secret")
@@ -93,21 +251,59 @@ func (h *Handler) PlaceStone(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if clockState(game, moves).Flagged(time.Now()) {
+		if status, msg := h.flag(game, color); status == http.StatusInternalServerError {
+			respondError(w, status, msg)
+			return
+		}
+		respondError(w, http.StatusConflict, "time is up: "+color.String()+" lost on time")
+		return
+	}
+
+	if err := g.Play(color, req.Col, req.Row); err != nil {
+		respondError(w, http.StatusBadRequest, "illegal move: "+err.Error())
+		return
+	}
+
+	moveOrder := nextMoveOrder(moves)
 	if err := h.repo.RecordMove(req.PlayID, req.Color, req.Col, req.Row, moveOrder); err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to record move")
 		return
 	}
+	h.publishMove(req.PlayID, color, req.Col, req.Row, moveOrder, false)
+
+	// Moving instead of answering declines the opponent's draw offer...
+	if game.DrawOffer != nil && *game.DrawOffer != req.Color {
+		if err := h.repo.SetDrawOffer(req.PlayID, nil); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to decline draw")
+			return
+		}
+		h.hub.Publish(model.GameEvent{Type: model.EventDrawDecline, PlayID: req.PlayID, Color: req.Color})
+	}
+	// and so does a takeback request
+	if game.TakebackRequest != nil && *game.TakebackRequest != req.Color {
+		if err := h.repo.SetTakebackRequest(req.PlayID, nil); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to decline takeback")
+			return
+		}
+		h.hub.Publish(model.GameEvent{Type: model.EventTakebackDecline, PlayID: req.PlayID, Color: req.Color})
+	}
+
+	if err := h.advance(req.PlayID, game, g, moveOrder+1); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
 }
 
-func (h *Handler) EndGame(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) JoinGame(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	var req model.EndGameRequest
+	var req model.JoinGameRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
@@ -118,30 +314,59 @@ func (h *Handler) EndGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var result string
-	if req.BlackCount > req.WhiteCount {
-		result = "black_win"
-	} else if req.WhiteCount > req.BlackCount {
-		result = "white_win"
-	} else {
-		result = "draw"
+	guestSecret := uuid.New().String()
+	if err := h.repo.SetGuestSecret(req.PlayID, guestSecret); err != nil {
+		if errors.Is(err, repository.ErrGuestAlreadyJoined) {
+			respondError(w, http.StatusConflict, "guest already joined or game not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to join game")
+		return
 	}
+	h.hub.Publish(model.GameEvent{Type: model.EventGuestJoined, PlayID: req.PlayID})
 
-	if err := h.repo.EndGame(req.PlayID, req.BlackCount, req.WhiteCount, result); err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to end game")
+	respondJSON(w, http.StatusOK, model.JoinGameResponse{GuestSecret: guestSecret})
+}
+
+func (h *Handler) Spectate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+	var req model.SpectateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.PlayID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+
+	// a player's secret is what lets someone in to watch a private game
+	if _, status, msg := h.watchGame(req.PlayID, req.Secret); status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+
+	token := uuid.New().String()
+	if err := h.repo.AddSpectator(req.PlayID, token); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to add spectator")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, model.SpectateResponse{SpectatorToken: token})
 }
 
-func (h *Handler) JoinGame(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	var req model.JoinGameRequest
+	var req model.ChatRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
@@ -151,18 +376,35 @@ func (h *Handler) JoinGame(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "play_id is required")
 		return
 	}
+	text := strings.TrimSpace(req.Message)
+	if text == "" || utf8.RuneCountInString(text) > maxChatLength {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("message must be between 1 and %d characters", maxChatLength))
+		return
+	}
 
-	guestSecret := uuid.New().String()
-	if err := h.repo.SetGuestSecret(req.PlayID, guestSecret); err != nil {
-		if errors.Is(err, repository.ErrGuestAlreadyJoined) {
-			respondError(w, http.StatusConflict, "guest already joined or game not found")
+	game, err := h.repo.GetGame(req.PlayID)
+	if err != nil {
+		if errors.Is(err, repository.ErrGameNotFound) {
+			respondError(w, http.StatusNotFound, "game not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "failed to join game")
+		respondError(w, http.StatusInternalServerError, "failed to get game")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, model.JoinGameResponse{GuestSecret: guestSecret})
+	// Only players may chat
+	color, ok := playerColor(game, req.Secret)
+	if !ok {
+		respondError(w, http.StatusForbidden, "invalid secret")
+		return
+	}
+
+	h.hub.Publish(model.GameEvent{
+		Type:   model.EventChat,
+		PlayID: req.PlayID,
+		Chat:   &model.ChatMessage{Color: color.String(), Text: text, SentAt: time.Now()},
+	})
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
 }
 
 func (h *Handler) PollMoves(w http.ResponseWriter, r *http.Request) {
@@ -182,13 +424,536 @@ func (h *Handler) PollMoves(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	moves, err := h.repo.GetMovesAfter(req.PlayID, req.AfterMoveOrder)
+	if req.WaitMS < 0 || req.WaitMS > maxPollWaitMS {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("wait_ms must be between 0 and %d", maxPollWaitMS))
+		return
+	}
+
+	game, status, msg := h.watchGame(req.PlayID, req.Token)
+	if status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+
+	var sub *hub.Subscription
+	if req.WaitMS > 0 {
+		select {
+		case h.waiters <- struct{}{}:
+			defer func() { <-h.waiters }()
+		default:
+			respondError(w, http.StatusServiceUnavailable, "too many waiting requests")
+			return
+		}
+		// Subscribe before reading the moves so a move recorded in between still wakes us
+		sub = h.hub.Subscribe(req.PlayID)
+		defer sub.Close()
+	}
+
+	resp, err := h.pollMoves(game, req.AfterMoveOrder, req.Takebacks)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get moves")
+		return
+	}
+	if len(resp.Moves) == 0 && resp.AfterMoveOrder == nil && sub != nil && h.waitForMove(r.Context(), game, sub, time.Duration(req.WaitMS)*time.Millisecond) {
+		if resp, err = h.pollMoves(game, req.AfterMoveOrder, req.Takebacks); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to get moves")
+			return
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) pollMoves(game *model.Game, afterMoveOrder int, takebacks *int) (model.PollMovesResponse, error) {
+	playID := game.PlayID
+	// the whole history is needed to name the opening
+	moves, err := h.repo.GetMovesAfter(playID, 0)
+	if err != nil {
+		return model.PollMovesResponse{}, err
+	}
+
+	resp := model.PollMovesResponse{Moves: []model.Move{}, Takebacks: game.Takebacks, Opening: h.openingName(game, moves)}
+	after, rewound, err := h.takebackCursor(game, afterMoveOrder, nextMoveOrder(moves)-1, takebacks)
+	if err != nil {
+		return model.PollMovesResponse{}, err
+	}
+	if rewound {
+		resp.AfterMoveOrder, afterMoveOrder = &after, after
+	}
+	for _, m := range moves {
+		if m.MoveOrder > afterMoveOrder {
+			resp.Moves = append(resp.Moves, m)
+		}
+	}
+	return resp, nil
+}
+
+// waitForMove blocks until sub sees a move, a pass or the end of the game,
+// and reports whether it did before wait elapsed or ctx was cancelled.
+// Finished games return at once since no move can follow.
+func (h *Handler) waitForMove(ctx context.Context, game *model.Game, sub *hub.Subscription, wait time.Duration) bool {
+	if game.Result != nil {
+		return false
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				// dropped for falling behind: plenty happened, let the client re-read
+				return true
+			}
+			switch ev.Type {
+			case model.EventMove, model.EventPass, model.EventGameOver, model.EventTakeback:
+				return true
+			}
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (h *Handler) GetGame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	playID := r.PathValue("play_id")
+	if playID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+
+	game, status, msg := h.watchGame(playID, r.URL.Query().Get("token"))
+	if status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+	moves, err := h.repo.GetMovesAfter(playID, 0)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to get moves")
 		return
 	}
+	g, err := replayGame(game, moves)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to replay moves")
+		return
+	}
+
+	resp := model.GameStateResponse{
+		PlayID:          game.PlayID,
+		StartPosition:   game.StartPosition,
+		LegalMoves:      []model.Position{},
+		BlackCount:      g.Board.Count(board.Black),
+		WhiteCount:      g.Board.Count(board.White),
+		MoveCount:       len(moves),
+		Passes:          []model.Move{},
+		Result:          game.Result,
+		Termination:     game.Termination,
+		DrawOffer:       game.DrawOffer,
+		TakebackRequest: game.TakebackRequest,
+		Takebacks:       game.Takebacks,
+		GuestJoined:     game.GuestSecret != nil,
+		BlackPlayer:     game.BlackPlayer,
+		WhitePlayer:     game.WhitePlayer,
+		Private:         game.Private,
+		SpectatorCount:  h.hub.Spectators(playID),
+		AILevel:         game.AILevel,
+		AIEngine:        game.AIEngine,
+		Opening:         h.openingName(game, moves),
+		TimeControl:     timeControl(game),
+		RematchOffer:    game.RematchOffer,
+		CreatedAt:       game.CreatedAt,
+		UpdatedAt:       game.UpdatedAt,
+	}
+	for row := 0; row < board.Size; row++ {
+		for col := 0; col < board.Size; col++ {
+			if c := g.Board.At(col, row); c != board.Empty {
+				resp.Board[row][col] = c.String()
+			}
+		}
+	}
+	if resp.RematchPlayID, err = h.rematchPlayID(game); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get rematch")
+		return
+	}
+	if resp.Series, err = h.series(game); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get series")
+		return
+	}
+	// a resigned game keeps stones on the board but nobody is to move
+	if game.Result == nil && !g.IsOver() {
+		resp.SideToMove = g.Turn.String()
+		for _, p := range g.Board.ValidMoves(g.Turn) {
+			resp.LegalMoves = append(resp.LegalMoves, model.Position{Col: p.Col, Row: p.Row})
+		}
+		if resp.TimeControl != nil {
+			resp.Clock = clockResponse(clockState(game, moves), time.Now())
+		}
+	}
+	for _, m := range moves {
+		if m.Pass {
+			resp.Passes = append(resp.Passes, m)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) AnalyzeEndgame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req model.AnalyzeEndgameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.PlayID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+
+	g, _, moveNumber, ok := h.loadPosition(w, req.PlayID, req.Token, req.MoveNumber)
+	if !ok {
+		return
+	}
+
+	resp := model.AnalyzeEndgameResponse{
+		PlayID:     req.PlayID,
+		MoveNumber: moveNumber,
+		Empties:    g.Board.Count(board.Empty),
+		DiscDiff:   g.Board.Count(board.Black) - g.Board.Count(board.White),
+		BestLine:   []model.LineMove{},
+	}
+	if g.IsOver() {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+	if resp.Empties > ai.MaxSolveEmpties {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("position has %d empty squares; at most %d can be solved", resp.Empties, ai.MaxSolveEmpties))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), analysisTimeout)
+	defer cancel()
+	sol, err := ai.Solve(ctx, *g.Board, g.Turn)
+	if err != nil {
+		respondError(w, http.StatusServiceUnavailable, "analysis timed out")
+		return
+	}
+
+	resp.SideToMove = g.Turn.String()
+	resp.DiscDiff = sol.Score
+	if g.Turn == board.White {
+		resp.DiscDiff = -sol.Score
+	}
+	resp.BestLine = lineMoves(g.Turn, sol.Line)
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) Hint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req model.HintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.PlayID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+	if req.TimeLimitMS < 0 || req.TimeLimitMS > int(analysisTimeout/time.Millisecond) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("time_limit_ms must be between 0 and %d", analysisTimeout/time.Millisecond))
+		return
+	}
+
+	g, _, moveNumber, ok := h.loadPosition(w, req.PlayID, req.Token, req.MoveNumber)
+	if !ok {
+		return
+	}
+
+	resp := model.HintResponse{PlayID: req.PlayID, MoveNumber: moveNumber, Moves: []model.HintMove{}}
+	if g.IsOver() {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp.SideToMove = g.Turn.String()
 
-	respondJSON(w, http.StatusOK, model.PollMovesResponse{Moves: moves})
+	timeLimit := defaultHintTimeLimit
+	if req.TimeLimitMS > 0 {
+		timeLimit = time.Duration(req.TimeLimitMS) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeLimit)
+	defer cancel()
+
+	var analysis ai.Analysis
+	if g.Board.Count(board.Empty) <= hintSolveEmpties {
+		// a solve that runs out of time falls back to the heuristic search,
+		// which keeps the other half of the time limit
+		solveCtx, cancelSolve := context.WithTimeout(ctx, timeLimit/2)
+		analysis, _ = ai.AnalyzeExact(solveCtx, *g.Board, g.Turn)
+		cancelSolve()
+	}
+	if !analysis.Exact {
+		analysis = ai.Analyze(ctx, *g.Board, g.Turn, hintMaxDepth)
+	}
+
+	resp.Exact, resp.Depth = analysis.Exact, analysis.Depth
+	for _, m := range analysis.Moves {
+		pos := board.PositionOf(m.Move)
+		hm := model.HintMove{Col: pos.Col, Row: pos.Row, Score: m.Score, Flips: []model.Position{}}
+		for _, f := range g.Board.Flips(g.Turn, pos.Col, pos.Row) {
+			hm.Flips = append(hm.Flips, model.Position{Col: f.Col, Row: f.Row})
+		}
+		hm.Line = lineMoves(g.Turn, m.Line)
+		resp.Moves = append(resp.Moves, hm)
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// watchGame loads a game on behalf of a reader holding token. On failure it
+// returns the status and message to reply with.
+func (h *Handler) watchGame(playID, token string) (*model.Game, int, string) {
+	game, err := h.repo.GetGame(playID)
+	if err != nil {
+		if errors.Is(err, repository.ErrGameNotFound) {
+			return nil, http.StatusNotFound, "game not found"
+		}
+		return nil, http.StatusInternalServerError, "failed to get game"
+	}
+	ok, err := h.canWatch(game, token)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "failed to check spectator token"
+	}
+	if !ok {
+		return nil, http.StatusForbidden, "game is private"
+	}
+	return game, http.StatusOK, ""
+}
+
+// canWatch reports whether token may read a game: anyone may read a public
+// game, only players and spectators a private one.
+func (h *Handler) canWatch(game *model.Game, token string) (bool, error) {
+	if !game.Private {
+		return true, nil
+	}
+	if token == "" {
+		return false, nil
+	}
+	if isPlayer(game, token) {
+		return true, nil
+	}
+	return h.repo.IsSpectator(game.PlayID, token)
+}
+
+// isPlayer reports whether token is the host or guest secret of game.
+func isPlayer(game *model.Game, token string) bool {
+	return token != "" && ((game.HostSecret != nil && token == *game.HostSecret) || (game.GuestSecret != nil && token == *game.GuestSecret))
+}
+
+// loadPosition replays playID up to moveNumber (nil for the latest move),
+// returning the moves played to get there. On failure the error response has
+// already been written.
+func (h *Handler) loadPosition(w http.ResponseWriter, playID, token string, moveNumber *int) (*board.Game, []model.Move, int, bool) {
+	game, status, msg := h.watchGame(playID, token)
+	if status != http.StatusOK {
+		respondError(w, status, msg)
+		return nil, nil, 0, false
+	}
+	moves, err := h.repo.GetMovesAfter(playID, 0)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get moves")
+		return nil, nil, 0, false
+	}
+	n := nextMoveOrder(moves) - 1
+	if moveNumber != nil {
+		if *moveNumber < 0 || *moveNumber > n {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("move_number must be between 0 and %d", n))
+			return nil, nil, 0, false
+		}
+		n = *moveNumber
+	}
+	played := movesUpTo(moves, n)
+	g, err := replayGame(game, played)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to replay moves")
+		return nil, nil, 0, false
+	}
+	return g, played, n, true
+}
+
+// lineMoves converts an engine line starting with mover into API moves.
+func lineMoves(mover board.Color, line []int) []model.LineMove {
+	out := []model.LineMove{}
+	for _, sq := range line {
+		lm := model.LineMove{Color: mover.String(), Col: -1, Row: -1, Pass: sq == ai.Pass}
+		if !lm.Pass {
+			pos := board.PositionOf(sq)
+			lm.Col, lm.Row = pos.Col, pos.Row
+		}
+		out = append(out, lm)
+		mover = mover.Opponent()
+	}
+	return out
+}
+
+// advance records everything that follows a player's move automatically:
+// forced passes, the AI opponent's replies and the end of the game.
+// moveOrder is the move_order of the next row to be written.
+func (h *Handler) advance(playID string, game *model.Game, g *board.Game, moveOrder int) error {
+	for !g.IsOver() {
+		turn := g.Turn
+		if g.MustPass() {
+			if err := g.Pass(turn); err != nil {
+				return errors.New("failed to apply pass")
+			}
+			if err := h.repo.RecordPass(playID, turn.String(), moveOrder); err != nil {
+				return errors.New("failed to record pass")
+			}
+			h.publishMove(playID, turn, -1, -1, moveOrder, true)
+			moveOrder++
+			continue
+		}
+
+		if game.AILevel == nil || turn != aiColor {
+			break
+		}
+		pos, ok := h.book.Move(*g.Board, turn)
+		if !ok {
+			pos, ok = aiMove(*g.Board, turn, aiSettings(game))
+		}
+		if !ok {
+			return errors.New("failed to find AI move")
+		}
+		if err := g.Play(turn, pos.Col, pos.Row); err != nil {
+			return errors.New("failed to apply AI move")
+		}
+		if err := h.repo.RecordMove(playID, turn.String(), pos.Col, pos.Row, moveOrder); err != nil {
+			return errors.New("failed to record AI move")
+		}
+		h.publishMove(playID, turn, pos.Col, pos.Row, moveOrder, false)
+		moveOrder++
+	}
+
+	if g.IsOver() {
+		blackCount, whiteCount := g.Board.Count(board.Black), g.Board.Count(board.White)
+		result := board.Result(blackCount, whiteCount)
+		if err := h.repo.EndGame(playID, blackCount, whiteCount, result, model.TerminationNormal); err != nil {
+			return errors.New("failed to end game")
+		}
+		h.publishGameOver(playID, blackCount, whiteCount, result, model.TerminationNormal)
+	}
+	return nil
+}
+
+// openingName labels a recorded game with the opening book. Games that did
+// not start from the standard setup have no opening.
+func (h *Handler) openingName(game *model.Game, moves []model.Move) string {
+	if game.StartPosition != nil {
+		return ""
+	}
+	squares := make([]int, 0, len(moves))
+	for _, m := range moves {
+		if m.Pass {
+			squares = append(squares, ai.Pass)
+			continue
+		}
+		squares = append(squares, board.Square(m.Col, m.Row))
+	}
+	return h.book.Name(squares)
+}
+
+// aiMove searches for the AI's reply within aiMoveTimeout.
+func aiMove(b board.Board, c board.Color, s ai.Settings) (board.Position, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), aiMoveTimeout)
+	defer cancel()
+	return ai.Move(ctx, b, c, s)
+}
+
+func aiSettings(game *model.Game) ai.Settings {
+	s := ai.Settings{Engine: ai.EngineAlphaBeta, Level: *game.AILevel}
+	if game.AIEngine != nil {
+		s.Engine = *game.AIEngine
+	}
+	if game.AIPlayouts != nil {
+		s.Playouts = *game.AIPlayouts
+	}
+	if game.AITimeLimitMS != nil {
+		s.TimeLimit = time.Duration(*game.AITimeLimitMS) * time.Millisecond
+	}
+	return s
+}
+
+// startingGame returns the position game starts from.
+func startingGame(game *model.Game) (*board.Game, error) {
+	if game.StartPosition == nil {
+		return board.NewGame(), nil
+	}
+	return board.ParsePosition(*game.StartPosition)
+}
+
+// replayGame rebuilds the game state from its starting position and recorded
+// moves and passes, validating each one against the rules.
+func replayGame(game *model.Game, moves []model.Move) (*board.Game, error) {
+	g, err := startingGame(game)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range moves {
+		if err := playMove(g, m); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// playMove applies a recorded move or pass to g.
+func playMove(g *board.Game, m model.Move) error {
+	color, ok := board.ParseColor(m.Color)
+	if !ok {
+		return fmt.Errorf("move %d: invalid color %q", m.MoveOrder, m.Color)
+	}
+	var err error
+	if m.Pass {
+		err = g.Pass(color)
+	} else {
+		err = g.Play(color, m.Col, m.Row)
+	}
+	if err != nil {
+		return fmt.Errorf("move %d: %w", m.MoveOrder, err)
+	}
+	return nil
+}
+
+// movesUpTo returns the prefix of moves with move_order <= moveNumber.
+func movesUpTo(moves []model.Move, moveNumber int) []model.Move {
+	n := 0
+	for n < len(moves) && moves[n].MoveOrder <= moveNumber {
+		n++
+	}
+	return moves[:n]
+}
+
+func nextMoveOrder(moves []model.Move) int {
+	if len(moves) == 0 {
+		return 1
+	}
+	return moves[len(moves)-1].MoveOrder + 1
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
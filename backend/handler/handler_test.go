@@ -2,13 +2,18 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/dog-nose/othello-backend/ai"
+	"github.com/dog-nose/othello-backend/board"
 	"github.com/dog-nose/othello-backend/model"
 	"github.com/dog-nose/othello-backend/repository"
 )
@@ -18,12 +23,31 @@ import (
 type mockRepository struct {
 	createGameFn           func(playID string) error
 	createGameWithSecretFn func(playID, hostSecret string) error
+	createAIGameFn         func(playID, hostSecret, engine string, level, playouts, timeLimitMS int) error
 	getGameFn              func(playID string) (*model.Game, error)
 	recordMoveFn           func(playID, color string, col, row, moveOrder int) error
+	recordPassFn           func(playID, color string, moveOrder int) error
 	getMoveCountFn         func(playID string) (int, error)
-	endGameFn              func(playID string, blackCount, whiteCount int, result string) error
+	endGameFn              func(playID string, blackCount, whiteCount int, result, termination string) error
 	setGuestSecretFn       func(playID, guestSecret string) error
 	getMovesAfterFn        func(playID string, afterMoveOrder int) ([]model.Move, error)
+	setPrivateFn           func(playID string) error
+	addSpectatorFn         func(playID, token string) error
+	isSpectatorFn          func(playID, token string) (bool, error)
+	setDrawOfferFn         func(playID string, color *string) error
+	setTimeControlFn       func(playID string, baseMS, incrementMS, moveMS int) error
+	setStartPositionFn     func(playID, position string) error
+	getTimedGamesFn        func() ([]model.Game, error)
+	setTakebackRequestFn   func(playID string, color *string) error
+	takeBackFn             func(playID string, fromMoveOrder int, requestedBy string) error
+	getFirstRevertedFn     func(playID string, upTo, sinceTakebacks int) (int, bool, error)
+	setRematchOfferFn      func(playID string, color *string) error
+	createRematchFn        func(playID, previousPlayID, hostSecret, guestSecret string) error
+	getRematchFn           func(playID string) (*model.Game, error)
+	getSeriesFn            func(seriesID string) ([]model.Game, error)
+	getInactiveGamesFn     func(idle time.Duration) ([]model.Game, error)
+	purgeAbandonedFn       func(age time.Duration, archive bool) (int64, error)
+	importGameFn           func(game *model.Game, moves []model.Move) error
 }
 
 func (m *mockRepository) CreateGame(playID string) error {
@@ -40,6 +64,13 @@ func (m *mockRepository) CreateGameWithSecret(playID, hostSecret string) error {
 	return nil
 }
 
+func (m *mockRepository) CreateAIGame(playID, hostSecret, engine string, level, playouts, timeLimitMS int) error {
+	if m.createAIGameFn != nil {
+		return m.createAIGameFn(playID, hostSecret, engine, level, playouts, timeLimitMS)
+	}
+	return nil
+}
+
 func (m *mockRepository) GetGame(playID string) (*model.Game, error) {
 	if m.getGameFn != nil {
 		return m.getGameFn(playID)
@@ -54,6 +85,13 @@ func (m *mockRepository) RecordMove(playID, color string, col, row, moveOrder in
 	return nil
 }
 
+func (m *mockRepository) RecordPass(playID, color string, moveOrder int) error {
+	if m.recordPassFn != nil {
+		return m.recordPassFn(playID, color, moveOrder)
+	}
+	return nil
+}
+
 func (m *mockRepository) GetMoveCount(playID string) (int, error) {
 	if m.getMoveCountFn != nil {
 		return m.getMoveCountFn(playID)
@@ -61,9 +99,9 @@ func (m *mockRepository) GetMoveCount(playID string) (int, error) {
 	return 0, nil
 }
 
-func (m *mockRepository) EndGame(playID string, blackCount, whiteCount int, result string) error {
+func (m *mockRepository) EndGame(playID string, blackCount, whiteCount int, result, termination string) error {
 	if m.endGameFn != nil {
-		return m.endGameFn(playID, blackCount, whiteCount, result)
+		return m.endGameFn(playID, blackCount, whiteCount, result, termination)
 	}
 	return nil
 }
@@ -82,6 +120,129 @@ func (m *mockRepository) GetMovesAfter(playID string, afterMoveOrder int) ([]mod
 	return []model.Move{}, nil
 }
 
+func (m *mockRepository) SetDrawOffer(playID string, color *string) error {
+	if m.setDrawOfferFn != nil {
+		return m.setDrawOfferFn(playID, color)
+	}
+	return nil
+}
+
+func (m *mockRepository) SetTimeControl(playID string, baseMS, incrementMS, moveMS int) error {
+	if m.setTimeControlFn != nil {
+		return m.setTimeControlFn(playID, baseMS, incrementMS, moveMS)
+	}
+	return nil
+}
+
+func (m *mockRepository) SetStartPosition(playID, position string) error {
+	if m.setStartPositionFn != nil {
+		return m.setStartPositionFn(playID, position)
+	}
+	return nil
+}
+
+func (m *mockRepository) GetTimedGames() ([]model.Game, error) {
+	if m.getTimedGamesFn != nil {
+		return m.getTimedGamesFn()
+	}
+	return []model.Game{}, nil
+}
+
+func (m *mockRepository) SetTakebackRequest(playID string, color *string) error {
+	if m.setTakebackRequestFn != nil {
+		return m.setTakebackRequestFn(playID, color)
+	}
+	return nil
+}
+
+func (m *mockRepository) TakeBack(playID string, fromMoveOrder int, requestedBy string) error {
+	if m.takeBackFn != nil {
+		return m.takeBackFn(playID, fromMoveOrder, requestedBy)
+	}
+	return nil
+}
+
+func (m *mockRepository) GetFirstRevertedMoveOrder(playID string, upTo, sinceTakebacks int) (int, bool, error) {
+	if m.getFirstRevertedFn != nil {
+		return m.getFirstRevertedFn(playID, upTo, sinceTakebacks)
+	}
+	return 0, false, nil
+}
+
+func (m *mockRepository) SetPrivate(playID string) error {
+	if m.setPrivateFn != nil {
+		return m.setPrivateFn(playID)
+	}
+	return nil
+}
+
+func (m *mockRepository) AddSpectator(playID, token string) error {
+	if m.addSpectatorFn != nil {
+		return m.addSpectatorFn(playID, token)
+	}
+	return nil
+}
+
+func (m *mockRepository) IsSpectator(playID, token string) (bool, error) {
+	if m.isSpectatorFn != nil {
+		return m.isSpectatorFn(playID, token)
+	}
+	return false, nil
+}
+
+func (m *mockRepository) SetRematchOffer(playID string, color *string) error {
+	if m.setRematchOfferFn != nil {
+		return m.setRematchOfferFn(playID, color)
+	}
+	return nil
+}
+
+func (m *mockRepository) CreateRematch(playID, previousPlayID, hostSecret, guestSecret string) error {
+	if m.createRematchFn != nil {
+		return m.createRematchFn(playID, previousPlayID, hostSecret, guestSecret)
+	}
+	return nil
+}
+
+func (m *mockRepository) GetRematch(playID string) (*model.Game, error) {
+	if m.getRematchFn != nil {
+		return m.getRematchFn(playID)
+	}
+	return nil, repository.ErrGameNotFound
+}
+
+func (m *mockRepository) GetSeries(seriesID string) ([]model.Game, error) {
+	if m.getSeriesFn != nil {
+		return m.getSeriesFn(seriesID)
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) GetInactiveGames(idle time.Duration) ([]model.Game, error) {
+	if m.getInactiveGamesFn != nil {
+		return m.getInactiveGamesFn(idle)
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) PurgeAbandonedGames(age time.Duration, archive bool) (int64, error) {
+	if m.purgeAbandonedFn != nil {
+		return m.purgeAbandonedFn(age, archive)
+	}
+	return 0, nil
+}
+
+func (m *mockRepository) ImportGame(game *model.Game, moves []model.Move) error {
+	if m.importGameFn != nil {
+		return m.importGameFn(game, moves)
+	}
+	return nil
+}
+
+func (m *mockRepository) ImportReferenceGames(games []model.ReferenceGame) (int64, error) {
+	return int64(len(games)), nil
+}
+
 func TestStartGame(t *testing.T) {
 	var calledPlayID, calledSecret string
 	mock := &mockRepository{
@@ -131,13 +292,154 @@ func TestStartGame_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestStartGame_AIOpponent(t *testing.T) {
+	var calledLevel int
+	var calledEngine string
+	mock := &mockRepository{
+		createGameWithSecretFn: func(playID, hostSecret string) error {
+			t.Fatal("expected CreateAIGame, not CreateGameWithSecret")
+			return nil
+		},
+		createAIGameFn: func(playID, hostSecret, engine string, level, playouts, timeLimitMS int) error {
+			calledLevel = level
+			calledEngine = engine
+			return nil
+		},
+	}
+	h := New(mock)
+
+	body := model.StartGameRequest{Opponent: "ai", Level: 3}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/start-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if calledLevel != 3 {
+		t.Fatalf("expected level 3, got %d", calledLevel)
+	}
+	if calledEngine != "alphabeta" {
+		t.Fatalf("expected default engine alphabeta, got %s", calledEngine)
+	}
+}
+
+func TestStartGame_MCTSOpponent(t *testing.T) {
+	var calledEngine string
+	var calledPlayouts, calledTimeLimit int
+	mock := &mockRepository{
+		createAIGameFn: func(playID, hostSecret, engine string, level, playouts, timeLimitMS int) error {
+			calledEngine = engine
+			calledPlayouts = playouts
+			calledTimeLimit = timeLimitMS
+			return nil
+		},
+	}
+	h := New(mock)
+
+	body := model.StartGameRequest{Opponent: "ai", Level: 2, Engine: "mcts", Playouts: 4000, TimeLimitMS: 1500}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/start-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if calledEngine != "mcts" || calledPlayouts != 4000 || calledTimeLimit != 1500 {
+		t.Fatalf("expected mcts with 4000 playouts and 1500ms, got %s %d %d", calledEngine, calledPlayouts, calledTimeLimit)
+	}
+}
+
+func TestStartGame_InvalidEngine(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	body := model.StartGameRequest{Opponent: "ai", Level: 2, Engine: "random"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/start-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestStartGame_InvalidPlayouts(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	body := model.StartGameRequest{Opponent: "ai", Level: 2, Engine: "mcts", Playouts: -1}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/start-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestStartGame_InvalidLevel(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	body := model.StartGameRequest{Opponent: "ai", Level: 99}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/start-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestStartGame_InvalidOpponent(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodPost, "/start-game", strings.NewReader(`{"opponent":"robot"}`))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestStartGame_InvalidBody(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodPost, "/start-game", strings.NewReader("bad json"))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
 func TestPlaceStone(t *testing.T) {
 	var recordedColor string
 	var recordedCol, recordedRow, recordedOrder int
 
 	mock := &mockRepository{
-		getMoveCountFn: func(playID string) (int, error) {
-			return 3, nil
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{
+				{PlayID: playID, Color: "black", Col: 3, Row: 2, MoveOrder: 1},
+				{PlayID: playID, Color: "white", Col: 2, Row: 2, MoveOrder: 2},
+			}, nil
 		},
 		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
 			recordedColor = color
@@ -170,17 +472,14 @@ func TestPlaceStone(t *testing.T) {
 	if recordedCol != 2 || recordedRow != 3 {
 		t.Fatalf("expected col=2, row=3, got col=%d, row=%d", recordedCol, recordedRow)
 	}
-	if recordedOrder != 4 {
-		t.Fatalf("expected move_order 4, got %d", recordedOrder)
+	if recordedOrder != 3 {
+		t.Fatalf("expected move_order 3, got %d", recordedOrder)
 	}
 }
 
 func TestPlaceStone_ValidSecret(t *testing.T) {
 	hostSecret := "host-secret-123"
 	mock := &mockRepository{
-		getMoveCountFn: func(playID string) (int, error) {
-			return 0, nil // move_order will be 1 (black/host)
-		},
 		getGameFn: func(playID string) (*model.Game, error) {
 			return &model.Game{PlayID: playID, HostSecret: &hostSecret}, nil
 		},
@@ -208,9 +507,6 @@ func TestPlaceStone_ValidSecret(t *testing.T) {
 func TestPlaceStone_WrongSecret(t *testing.T) {
 	hostSecret := "host-secret-123"
 	mock := &mockRepository{
-		getMoveCountFn: func(playID string) (int, error) {
-			return 0, nil // move_order will be 1 (black/host)
-		},
 		getGameFn: func(playID string) (*model.Game, error) {
 			return &model.Game{PlayID: playID, HostSecret: &hostSecret}, nil
 		},
@@ -239,12 +535,12 @@ func TestPlaceStone_GuestSecret(t *testing.T) {
 	hostSecret := "host-secret-123"
 	guestSecret := "guest-secret-456"
 	mock := &mockRepository{
-		getMoveCountFn: func(playID string) (int, error) {
-			return 1, nil // move_order will be 2 (white/guest)
-		},
 		getGameFn: func(playID string) (*model.Game, error) {
 			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret}, nil
 		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "black", Col: 2, Row: 3, MoveOrder: 1}}, nil
+		},
 	}
 	h := New(mock)
 
@@ -252,7 +548,7 @@ func TestPlaceStone_GuestSecret(t *testing.T) {
 		PlayID: "test-id",
 		Color:  "white",
 		Col:    2,
-		Row:    3,
+		Row:    2,
 		Secret: "guest-secret-456",
 	}
 	b, _ := json.Marshal(body)
@@ -269,9 +565,6 @@ func TestPlaceStone_GuestSecret(t *testing.T) {
 func TestPlaceStone_NoSecretLegacyGame(t *testing.T) {
 	// Legacy games (no host_secret) should skip secret validation
 	mock := &mockRepository{
-		getMoveCountFn: func(playID string) (int, error) {
-			return 0, nil
-		},
 		getGameFn: func(playID string) (*model.Game, error) {
 			return &model.Game{PlayID: playID}, nil
 		},
@@ -337,233 +630,377 @@ func TestPlaceStone_InvalidPosition(t *testing.T) {
 	}
 }
 
-func TestEndGame_BlackWin(t *testing.T) {
+func TestPlaceStone_EndsGameWhenNoMovesRemain(t *testing.T) {
+	var recordedBlack, recordedWhite int
 	var recordedResult string
 	mock := &mockRepository{
-		endGameFn: func(playID string, blackCount, whiteCount int, result string) error {
+		// A nine-move game: black wipes out white with the last move at f4
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{
+				{PlayID: playID, Color: "black", Col: 3, Row: 2, MoveOrder: 1},
+				{PlayID: playID, Color: "white", Col: 2, Row: 2, MoveOrder: 2},
+				{PlayID: playID, Color: "black", Col: 1, Row: 2, MoveOrder: 3},
+				{PlayID: playID, Color: "white", Col: 3, Row: 1, MoveOrder: 4},
+				{PlayID: playID, Color: "black", Col: 4, Row: 0, MoveOrder: 5},
+				{PlayID: playID, Color: "white", Col: 3, Row: 5, MoveOrder: 6},
+				{PlayID: playID, Color: "black", Col: 3, Row: 6, MoveOrder: 7},
+				{PlayID: playID, Color: "white", Col: 4, Row: 2, MoveOrder: 8},
+			}, nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, result, termination string) error {
+			recordedBlack = blackCount
+			recordedWhite = whiteCount
 			recordedResult = result
 			return nil
 		},
 	}
 	h := New(mock)
 
-	body := model.EndGameRequest{
-		PlayID:     "test-id",
-		BlackCount: 40,
-		WhiteCount: 24,
-	}
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 5, Row: 3}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.EndGame(rec, req)
+	h.PlaceStone(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", rec.Code)
 	}
-	if recordedResult != "black_win" {
-		t.Fatalf("expected result black_win, got %s", recordedResult)
+	if recordedResult != "black_win" || recordedBlack != 13 || recordedWhite != 0 {
+		t.Fatalf("expected black_win 13/0, got %s %d/%d", recordedResult, recordedBlack, recordedWhite)
 	}
 }
 
-func TestEndGame_WhiteWin(t *testing.T) {
-	var recordedResult string
+func TestPlaceStone_GameAlreadyOver(t *testing.T) {
+	result := "white_win"
 	mock := &mockRepository{
-		endGameFn: func(playID string, blackCount, whiteCount int, result string) error {
-			recordedResult = result
-			return nil
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, Result: &result}, nil
 		},
 	}
 	h := New(mock)
 
-	body := model.EndGameRequest{
-		PlayID:     "test-id",
-		BlackCount: 20,
-		WhiteCount: 44,
-	}
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 3}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.EndGame(rec, req)
+	h.PlaceStone(rec, req)
 
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected status 200, got %d", rec.Code)
-	}
-	if recordedResult != "white_win" {
-		t.Fatalf("expected result white_win, got %s", recordedResult)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
 	}
 }
 
-func TestEndGame_Draw(t *testing.T) {
-	var recordedResult string
+func TestPlaceStone_AIReplies(t *testing.T) {
+	hostSecret := "host-secret-123"
+	level := 2
+	var recorded []model.Move
 	mock := &mockRepository{
-		endGameFn: func(playID string, blackCount, whiteCount int, result string) error {
-			recordedResult = result
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, AILevel: &level}, nil
+		},
+		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
+			recorded = append(recorded, model.Move{Color: color, Col: col, Row: row, MoveOrder: moveOrder})
 			return nil
 		},
 	}
 	h := New(mock)
 
-	body := model.EndGameRequest{
-		PlayID:     "test-id",
-		BlackCount: 32,
-		WhiteCount: 32,
-	}
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 3, Secret: hostSecret}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.EndGame(rec, req)
+	h.PlaceStone(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", rec.Code)
 	}
-	if recordedResult != "draw" {
-		t.Fatalf("expected result draw, got %s", recordedResult)
+	if len(recorded) != 2 {
+		t.Fatalf("expected human move and AI reply, got %d moves", len(recorded))
+	}
+	reply := recorded[1]
+	if reply.Color != "white" || reply.MoveOrder != 2 {
+		t.Fatalf("expected white reply at move_order 2, got %+v", reply)
+	}
+	g := board.NewGame()
+	g.Play(board.Black, 2, 3)
+	if !g.Board.IsValidMove(board.White, reply.Col, reply.Row) {
+		t.Fatalf("expected AI reply to be legal, got (%d,%d)", reply.Col, reply.Row)
 	}
 }
 
-func TestEndGame_MissingPlayID(t *testing.T) {
-	mock := &mockRepository{}
+func TestPlaceStone_AIPlaysBookMove(t *testing.T) {
+	hostSecret := "host-secret-123"
+	level := 2
+	var recorded []model.Move
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, AILevel: &level}, nil
+		},
+		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
+			recorded = append(recorded, model.Move{Color: color, Col: col, Row: row, MoveOrder: moveOrder})
+			return nil
+		},
+	}
 	h := New(mock)
 
-	body := model.EndGameRequest{
-		BlackCount: 32,
-		WhiteCount: 32,
-	}
+	// c4 is f5 rotated by 180 degrees, so the book answer d6 becomes e3
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 3, Secret: hostSecret}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.EndGame(rec, req)
+	h.PlaceStone(rec, req)
 
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("expected status 400, got %d", rec.Code)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if len(recorded) != 2 || recorded[1].Col != 4 || recorded[1].Row != 2 {
+		t.Fatalf("expected white book reply e3, got %+v", recorded)
 	}
 }
 
-func TestStartGame_CreateGameError(t *testing.T) {
+func TestPlaceStone_MCTSReplies(t *testing.T) {
+	hostSecret := "host-secret-123"
+	level := 1
+	engine := "mcts"
+	playouts := 200
+	var recorded []model.Move
 	mock := &mockRepository{
-		createGameWithSecretFn: func(playID, hostSecret string) error {
-			return fmt.Errorf("db error")
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, AILevel: &level, AIEngine: &engine, AIPlayouts: &playouts}, nil
+		},
+		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
+			recorded = append(recorded, model.Move{Color: color, Col: col, Row: row, MoveOrder: moveOrder})
+			return nil
 		},
 	}
 	h := New(mock)
 
-	req := httptest.NewRequest(http.MethodPost, "/start-game", nil)
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 3, Secret: hostSecret}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.StartGame(rec, req)
+	h.PlaceStone(rec, req)
 
-	if rec.Code != http.StatusInternalServerError {
-		t.Fatalf("expected status 500, got %d", rec.Code)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if len(recorded) != 2 || recorded[1].Color != "white" {
+		t.Fatalf("expected a white MCTS reply, got %+v", recorded)
 	}
 }
 
-func TestPlaceStone_MethodNotAllowed(t *testing.T) {
-	mock := &mockRepository{}
+func TestPlaceStone_AIRepliesAfterDisconnect(t *testing.T) {
+	hostSecret := "host-secret-123"
+	level := 4
+	// a middlegame out of the book with black to move, deep enough that a
+	// cancelled search settles for a different reply
+	moves := randomGameMoves("test-id", 6, 40)
+	g := board.NewGame()
+	for _, m := range moves {
+		if err := playMove(g, m); err != nil {
+			t.Fatalf("invalid move %+v: %v", m, err)
+		}
+	}
+	if g.Turn != board.Black {
+		t.Fatalf("expected black to move, got %v", g.Turn)
+	}
+	play := g.Board.ValidMoves(board.Black)[0]
+	g.Play(board.Black, play.Col, play.Row)
+	want, _ := ai.Move(context.Background(), *g.Board, board.White, ai.Settings{Engine: ai.EngineAlphaBeta, Level: level})
+
+	var recorded []model.Move
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, AILevel: &level}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return movesAfter(moves, afterMoveOrder), nil
+		},
+		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
+			recorded = append(recorded, model.Move{Color: color, Col: col, Row: row, MoveOrder: moveOrder})
+			return nil
+		},
+	}
 	h := New(mock)
 
-	req := httptest.NewRequest(http.MethodGet, "/place-stone", nil)
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: play.Col, Row: play.Row, Secret: hostSecret}
+	b, _ := json.Marshal(body)
+	// the client is gone before the AI starts thinking
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b)).WithContext(ctx)
 	rec := httptest.NewRecorder()
 
 	h.PlaceStone(rec, req)
 
-	if rec.Code != http.StatusMethodNotAllowed {
-		t.Fatalf("expected status 405, got %d", rec.Code)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("expected human move and AI reply, got %d moves", len(recorded))
+	}
+	if reply := recorded[1]; reply.Col != want.Col || reply.Row != want.Row {
+		t.Fatalf("expected the searched reply (%d,%d), got (%d,%d)", want.Col, want.Row, reply.Col, reply.Row)
 	}
 }
 
-func TestPlaceStone_InvalidBody(t *testing.T) {
-	mock := &mockRepository{}
+func TestPlaceStone_AIColorRejected(t *testing.T) {
+	hostSecret := "host-secret-123"
+	level := 1
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, AILevel: &level}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "black", Col: 2, Row: 3, MoveOrder: 1}}, nil
+		},
+	}
 	h := New(mock)
 
-	req := httptest.NewRequest(http.MethodPost, "/place-stone", strings.NewReader("invalid json"))
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "white", Col: 2, Row: 2}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
 	h.PlaceStone(rec, req)
 
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("expected status 400, got %d", rec.Code)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", rec.Code)
 	}
 }
 
-func TestPlaceStone_MissingPlayID(t *testing.T) {
-	mock := &mockRepository{}
+func TestEndGame_HostResigns(t *testing.T) {
+	hostSecret := "host-secret-123"
+	guestSecret := "guest-secret-456"
+	var recordedResult string
+	var recordedBlack, recordedWhite int
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "black", Col: 2, Row: 3, MoveOrder: 1}}, nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, result, termination string) error {
+			recordedResult = result
+			recordedBlack = blackCount
+			recordedWhite = whiteCount
+			return nil
+		},
+	}
 	h := New(mock)
 
-	body := model.PlaceStoneRequest{Color: "black", Col: 0, Row: 0}
+	body := model.EndGameRequest{PlayID: "test-id", Secret: hostSecret}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.PlaceStone(rec, req)
+	h.EndGame(rec, req)
 
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("expected status 400, got %d", rec.Code)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if recordedResult != "white_win" {
+		t.Fatalf("expected result white_win, got %s", recordedResult)
+	}
+	if recordedBlack != 4 || recordedWhite != 1 {
+		t.Fatalf("expected server-computed counts 4/1, got %d/%d", recordedBlack, recordedWhite)
 	}
 }
 
-func TestPlaceStone_GetMoveCountError(t *testing.T) {
+func TestEndGame_GuestResigns(t *testing.T) {
+	hostSecret := "host-secret-123"
+	guestSecret := "guest-secret-456"
+	var recordedResult string
 	mock := &mockRepository{
-		getMoveCountFn: func(playID string) (int, error) {
-			return 0, fmt.Errorf("db error")
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret}, nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, result, termination string) error {
+			recordedResult = result
+			return nil
 		},
 	}
 	h := New(mock)
 
-	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 0, Row: 0}
+	body := model.EndGameRequest{PlayID: "test-id", Secret: guestSecret}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.PlaceStone(rec, req)
+	h.EndGame(rec, req)
 
-	if rec.Code != http.StatusInternalServerError {
-		t.Fatalf("expected status 500, got %d", rec.Code)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if recordedResult != "black_win" {
+		t.Fatalf("expected result black_win, got %s", recordedResult)
 	}
 }
 
-func TestPlaceStone_RecordMoveError(t *testing.T) {
+func TestEndGame_InvalidSecret(t *testing.T) {
+	hostSecret := "host-secret-123"
+	called := false
 	mock := &mockRepository{
-		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
-			return fmt.Errorf("db error")
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret}, nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, result, termination string) error {
+			called = true
+			return nil
 		},
 	}
 	h := New(mock)
 
-	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 0, Row: 0}
+	body := model.EndGameRequest{PlayID: "test-id", Secret: "wrong-secret"}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.PlaceStone(rec, req)
+	h.EndGame(rec, req)
 
-	if rec.Code != http.StatusInternalServerError {
-		t.Fatalf("expected status 500, got %d", rec.Code)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", rec.Code)
+	}
+	if called {
+		t.Fatal("expected EndGame not to be called")
 	}
 }
 
-func TestEndGame_MethodNotAllowed(t *testing.T) {
-	mock := &mockRepository{}
+func TestEndGame_AlreadyOver(t *testing.T) {
+	hostSecret := "host-secret-123"
+	result := "black_win"
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, Result: &result}, nil
+		},
+	}
 	h := New(mock)
 
-	req := httptest.NewRequest(http.MethodGet, "/end-game", nil)
+	body := model.EndGameRequest{PlayID: "test-id", Secret: hostSecret}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
 	h.EndGame(rec, req)
 
-	if rec.Code != http.StatusMethodNotAllowed {
-		t.Fatalf("expected status 405, got %d", rec.Code)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
 	}
 }
 
-func TestEndGame_InvalidBody(t *testing.T) {
+func TestEndGame_MissingPlayID(t *testing.T) {
 	mock := &mockRepository{}
 	h := New(mock)
 
-	req := httptest.NewRequest(http.MethodPost, "/end-game", strings.NewReader("bad json"))
+	body := model.EndGameRequest{Secret: "host-secret-123"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
 	h.EndGame(rec, req)
@@ -573,202 +1010,1516 @@ func TestEndGame_InvalidBody(t *testing.T) {
 	}
 }
 
-func TestEndGame_EndGameError(t *testing.T) {
+func TestStartGame_CreateGameError(t *testing.T) {
 	mock := &mockRepository{
-		endGameFn: func(playID string, blackCount, whiteCount int, result string) error {
+		createGameWithSecretFn: func(playID, hostSecret string) error {
 			return fmt.Errorf("db error")
 		},
 	}
 	h := New(mock)
 
-	body := model.EndGameRequest{PlayID: "test-id", BlackCount: 32, WhiteCount: 32}
-	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/start-game", nil)
 	rec := httptest.NewRecorder()
 
-	h.EndGame(rec, req)
+	h.StartGame(rec, req)
 
 	if rec.Code != http.StatusInternalServerError {
 		t.Fatalf("expected status 500, got %d", rec.Code)
 	}
 }
 
-// JoinGame tests
-
-func TestJoinGame(t *testing.T) {
-	var calledPlayID, calledGuestSecret string
-	mock := &mockRepository{
-		setGuestSecretFn: func(playID, guestSecret string) error {
-			calledPlayID = playID
-			calledGuestSecret = guestSecret
-			return nil
-		},
-	}
+func TestPlaceStone_MethodNotAllowed(t *testing.T) {
+	mock := &mockRepository{}
 	h := New(mock)
 
-	body := model.JoinGameRequest{PlayID: "game-123"}
-	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/join-game", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodGet, "/place-stone", nil)
 	rec := httptest.NewRecorder()
 
-	h.JoinGame(rec, req)
-
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected status 200, got %d", rec.Code)
-	}
+	h.PlaceStone(rec, req)
 
-	var resp model.JoinGameResponse
-	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
-	}
-	if resp.GuestSecret == "" {
-		t.Fatal("expected guest_secret to be non-empty")
-	}
-	if calledPlayID != "game-123" {
-		t.Fatalf("expected play_id game-123, got %s", calledPlayID)
-	}
-	if calledGuestSecret == "" {
-		t.Fatal("expected SetGuestSecret to be called with non-empty secret")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
 	}
 }
 
-func TestJoinGame_AlreadyJoined(t *testing.T) {
-	mock := &mockRepository{
-		setGuestSecretFn: func(playID, guestSecret string) error {
-			return repository.ErrGuestAlreadyJoined
-		},
-	}
+func TestPlaceStone_InvalidBody(t *testing.T) {
+	mock := &mockRepository{}
 	h := New(mock)
 
-	body := model.JoinGameRequest{PlayID: "game-123"}
-	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/join-game", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", strings.NewReader("invalid json"))
 	rec := httptest.NewRecorder()
 
-	h.JoinGame(rec, req)
+	h.PlaceStone(rec, req)
 
-	if rec.Code != http.StatusConflict {
-		t.Fatalf("expected status 409, got %d", rec.Code)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
 	}
 }
 
-func TestJoinGame_MissingPlayID(t *testing.T) {
+func TestPlaceStone_MissingPlayID(t *testing.T) {
 	mock := &mockRepository{}
 	h := New(mock)
 
-	body := model.JoinGameRequest{}
+	body := model.PlaceStoneRequest{Color: "black", Col: 0, Row: 0}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/join-game", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.JoinGame(rec, req)
+	h.PlaceStone(rec, req)
 
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("expected status 400, got %d", rec.Code)
 	}
 }
 
-func TestJoinGame_MethodNotAllowed(t *testing.T) {
-	mock := &mockRepository{}
+func TestPlaceStone_GetGameError(t *testing.T) {
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return nil, fmt.Errorf("db error")
+		},
+	}
 	h := New(mock)
 
-	req := httptest.NewRequest(http.MethodGet, "/join-game", nil)
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 3}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.JoinGame(rec, req)
+	h.PlaceStone(rec, req)
 
-	if rec.Code != http.StatusMethodNotAllowed {
-		t.Fatalf("expected status 405, got %d", rec.Code)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
 	}
 }
 
-// PollMoves tests
-
-func TestPollMoves(t *testing.T) {
+func TestPlaceStone_RecordMoveError(t *testing.T) {
 	mock := &mockRepository{
-		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
-			return []model.Move{
-				{PlayID: playID, Color: "black", Col: 2, Row: 3, MoveOrder: 2},
-				{PlayID: playID, Color: "white", Col: 4, Row: 5, MoveOrder: 3},
-			}, nil
+		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
+			return fmt.Errorf("db error")
 		},
 	}
 	h := New(mock)
 
-	body := model.PollMovesRequest{PlayID: "game-123", AfterMoveOrder: 1}
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 3}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/poll-moves", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.PollMoves(rec, req)
-
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected status 200, got %d", rec.Code)
-	}
+	h.PlaceStone(rec, req)
 
-	var resp model.PollMovesResponse
-	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
-	}
-	if len(resp.Moves) != 2 {
-		t.Fatalf("expected 2 moves, got %d", len(resp.Moves))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
 	}
 }
 
-func TestPollMoves_NoNewMoves(t *testing.T) {
+func TestPlaceStone_OccupiedSquare(t *testing.T) {
+	recorded := false
 	mock := &mockRepository{
-		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
-			return []model.Move{}, nil
+		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
+			recorded = true
+			return nil
 		},
 	}
 	h := New(mock)
 
-	body := model.PollMovesRequest{PlayID: "game-123", AfterMoveOrder: 5}
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 3, Row: 3}
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/poll-moves", bytes.NewReader(b))
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
 	rec := httptest.NewRecorder()
 
-	h.PollMoves(rec, req)
+	h.PlaceStone(rec, req)
 
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected status 200, got %d", rec.Code)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
 	}
+	if recorded {
+		t.Fatal("expected illegal move not to be recorded")
+	}
+}
 
+func TestPlaceStone_NoFlips(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 0, Row: 0}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+
+	var resp model.SuccessResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(resp.Message, "illegal move") {
+		t.Fatalf("expected illegal move message, got %q", resp.Message)
+	}
+}
+
+func TestPlaceStone_GetMovesError(t *testing.T) {
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return nil, fmt.Errorf("db error")
+		},
+	}
+	h := New(mock)
+
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 3}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
+	}
+}
+
+func TestPlaceStone_CorruptHistory(t *testing.T) {
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "black", Col: 0, Row: 0, MoveOrder: 1}}, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 3}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
+	}
+}
+
+// passGameMoves is a legal opening after which black has no move and must pass.
+func passGameMoves(playID string) []model.Move {
+	seq := [][2]int{{3, 2}, {2, 2}, {1, 2}, {1, 1}, {5, 4}, {0, 2}, {0, 0}, {2, 0}}
+	moves := make([]model.Move, 0, len(seq))
+	for i, sq := range seq {
+		color := "black"
+		if i%2 == 1 {
+			color = "white"
+		}
+		moves = append(moves, model.Move{PlayID: playID, Color: color, Col: sq[0], Row: sq[1], MoveOrder: i + 1})
+	}
+	return moves
+}
+
+func TestPlaceStone_NotYourTurn(t *testing.T) {
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "black", Col: 2, Row: 3, MoveOrder: 1}}, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "black", Col: 2, Row: 2}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestPlaceStone_RecordsAutomaticPass(t *testing.T) {
+	var passColor string
+	var moveOrder, passOrder int
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return passGameMoves(playID)[:7], nil
+		},
+		recordMoveFn: func(playID, color string, col, row, order int) error {
+			moveOrder = order
+			return nil
+		},
+		recordPassFn: func(playID, color string, order int) error {
+			passColor = color
+			passOrder = order
+			return nil
+		},
+	}
+	h := New(mock)
+
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "white", Col: 2, Row: 0}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if moveOrder != 8 {
+		t.Fatalf("expected move_order 8, got %d", moveOrder)
+	}
+	if passColor != "black" || passOrder != 9 {
+		t.Fatalf("expected black pass at move_order 9, got %s at %d", passColor, passOrder)
+	}
+}
+
+func TestPlaceStone_RecordPassError(t *testing.T) {
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return passGameMoves(playID)[:7], nil
+		},
+		recordPassFn: func(playID, color string, order int) error {
+			return fmt.Errorf("db error")
+		},
+	}
+	h := New(mock)
+
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "white", Col: 2, Row: 0}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
+	}
+}
+
+func TestPlaceStone_TurnAfterPass(t *testing.T) {
+	hostSecret := "host-secret-123"
+	guestSecret := "guest-secret-456"
+	var recordedOrder int
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			moves := passGameMoves(playID)
+			return append(moves, model.Move{PlayID: playID, Color: "black", Col: -1, Row: -1, MoveOrder: 9, Pass: true}), nil
+		},
+		recordMoveFn: func(playID, color string, col, row, order int) error {
+			recordedOrder = order
+			return nil
+		},
+	}
+	h := New(mock)
+
+	// White moves again after black's pass, even though move_order 10 is even
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "white", Col: 4, Row: 2, Secret: guestSecret}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if recordedOrder != 10 {
+		t.Fatalf("expected move_order 10, got %d", recordedOrder)
+	}
+}
+
+func TestPlaceStone_HostSecretRejectedOnGuestTurn(t *testing.T) {
+	hostSecret := "host-secret-123"
+	guestSecret := "guest-secret-456"
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "black", Col: 2, Row: 3, MoveOrder: 1}}, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.PlaceStoneRequest{PlayID: "test-id", Color: "white", Col: 2, Row: 2, Secret: hostSecret}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PlaceStone(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", rec.Code)
+	}
+}
+
+func TestEndGame_MethodNotAllowed(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/end-game", nil)
+	rec := httptest.NewRecorder()
+
+	h.EndGame(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestEndGame_InvalidBody(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodPost, "/end-game", strings.NewReader("bad json"))
+	rec := httptest.NewRecorder()
+
+	h.EndGame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestEndGame_EndGameError(t *testing.T) {
+	hostSecret := "host-secret-123"
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret}, nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, result, termination string) error {
+			return fmt.Errorf("db error")
+		},
+	}
+	h := New(mock)
+
+	body := model.EndGameRequest{PlayID: "test-id", Secret: hostSecret}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.EndGame(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", rec.Code)
+	}
+}
+
+// JoinGame tests
+
+func TestJoinGame(t *testing.T) {
+	var calledPlayID, calledGuestSecret string
+	mock := &mockRepository{
+		setGuestSecretFn: func(playID, guestSecret string) error {
+			calledPlayID = playID
+			calledGuestSecret = guestSecret
+			return nil
+		},
+	}
+	h := New(mock)
+
+	body := model.JoinGameRequest{PlayID: "game-123"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/join-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.JoinGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var resp model.JoinGameResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.GuestSecret == "" {
+		t.Fatal("expected guest_secret to be non-empty")
+	}
+	if calledPlayID != "game-123" {
+		t.Fatalf("expected play_id game-123, got %s", calledPlayID)
+	}
+	if calledGuestSecret == "" {
+		t.Fatal("expected SetGuestSecret to be called with non-empty secret")
+	}
+}
+
+func TestJoinGame_AlreadyJoined(t *testing.T) {
+	mock := &mockRepository{
+		setGuestSecretFn: func(playID, guestSecret string) error {
+			return repository.ErrGuestAlreadyJoined
+		},
+	}
+	h := New(mock)
+
+	body := model.JoinGameRequest{PlayID: "game-123"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/join-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.JoinGame(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestJoinGame_MissingPlayID(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	body := model.JoinGameRequest{}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/join-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.JoinGame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestJoinGame_MethodNotAllowed(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/join-game", nil)
+	rec := httptest.NewRecorder()
+
+	h.JoinGame(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
+
+// PollMoves tests
+
+func TestPollMoves(t *testing.T) {
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{
+				{PlayID: playID, Color: "black", Col: 2, Row: 3, MoveOrder: 2},
+				{PlayID: playID, Color: "white", Col: 4, Row: 5, MoveOrder: 3},
+			}, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.PollMovesRequest{PlayID: "game-123", AfterMoveOrder: 1}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/poll-moves", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PollMoves(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var resp model.PollMovesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Moves) != 2 {
+		t.Fatalf("expected 2 moves, got %d", len(resp.Moves))
+	}
+}
+
+func TestPollMoves_NoNewMoves(t *testing.T) {
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{}, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.PollMovesRequest{PlayID: "game-123", AfterMoveOrder: 5}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/poll-moves", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PollMoves(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var resp model.PollMovesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Moves) != 0 {
+		t.Fatalf("expected 0 moves, got %d", len(resp.Moves))
+	}
+}
+
+func TestPollMoves_MissingPlayID(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	body := model.PollMovesRequest{}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/poll-moves", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PollMoves(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestPollMoves_MethodNotAllowed(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/poll-moves", nil)
+	rec := httptest.NewRecorder()
+
+	h.PollMoves(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
+
+// AnalyzeEndgame tests
+
+// randomGameMoves plays seeded random legal moves, recording passes, until at
+// most empties squares are left or the game ends.
+func randomGameMoves(playID string, seed int64, empties int) []model.Move {
+	rng := rand.New(rand.NewSource(seed))
+	g := board.NewGame()
+	var moves []model.Move
+	for !g.IsOver() && g.Board.Count(board.Empty) > empties {
+		order := len(moves) + 1
+		turn := g.Turn
+		if g.MustPass() {
+			g.Pass(turn)
+			moves = append(moves, model.Move{PlayID: playID, Color: turn.String(), Col: -1, Row: -1, MoveOrder: order, Pass: true})
+			continue
+		}
+		valid := g.Board.ValidMoves(turn)
+		m := valid[rng.Intn(len(valid))]
+		g.Play(turn, m.Col, m.Row)
+		moves = append(moves, model.Move{PlayID: playID, Color: turn.String(), Col: m.Col, Row: m.Row, MoveOrder: order})
+	}
+	return moves
+}
+
+func TestAnalyzeEndgame(t *testing.T) {
+	moves := randomGameMoves("game-123", 7, 10)
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.AnalyzeEndgameRequest{PlayID: "game-123"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/analyze-endgame", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.AnalyzeEndgame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.AnalyzeEndgameResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.MoveNumber != len(moves) || resp.Empties > 10 {
+		t.Fatalf("expected latest position with <=10 empties, got move %d with %d empties", resp.MoveNumber, resp.Empties)
+	}
+	if len(resp.BestLine) == 0 {
+		t.Fatal("expected a best line")
+	}
+
+	// Playing out the line must reach the reported disc differential
+	g, _ := replayGame(&model.Game{}, moves)
+	for _, lm := range resp.BestLine {
+		color, _ := board.ParseColor(lm.Color)
+		var err error
+		if lm.Pass {
+			err = g.Pass(color)
+		} else {
+			err = g.Play(color, lm.Col, lm.Row)
+		}
+		if err != nil {
+			t.Fatalf("expected best line to be legal, got %v", err)
+		}
+	}
+	if !g.IsOver() {
+		t.Fatal("expected best line to finish the game")
+	}
+	if diff := g.Board.Count(board.Black) - g.Board.Count(board.White); diff != resp.DiscDiff {
+		t.Fatalf("expected disc_diff %d, got %d", diff, resp.DiscDiff)
+	}
+}
+
+func TestAnalyzeEndgame_TooManyEmpties(t *testing.T) {
+	moves := randomGameMoves("game-123", 7, 10)
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	}
+	h := New(mock)
+
+	moveNumber := 10
+	body := model.AnalyzeEndgameRequest{PlayID: "game-123", MoveNumber: &moveNumber}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/analyze-endgame", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.AnalyzeEndgame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestAnalyzeEndgame_MoveNumberOutOfRange(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	moveNumber := 5
+	body := model.AnalyzeEndgameRequest{PlayID: "game-123", MoveNumber: &moveNumber}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/analyze-endgame", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.AnalyzeEndgame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestAnalyzeEndgame_FinishedGame(t *testing.T) {
+	moves := randomGameMoves("game-123", 7, 0)
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.AnalyzeEndgameRequest{PlayID: "game-123"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/analyze-endgame", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.AnalyzeEndgame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.AnalyzeEndgameResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SideToMove != "" || len(resp.BestLine) != 0 {
+		t.Fatalf("expected no side to move and an empty line, got %q and %d moves", resp.SideToMove, len(resp.BestLine))
+	}
+}
+
+func TestAnalyzeEndgame_MissingPlayID(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodPost, "/analyze-endgame", strings.NewReader(`{}`))
+	rec := httptest.NewRecorder()
+
+	h.AnalyzeEndgame(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestAnalyzeEndgame_MethodNotAllowed(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/analyze-endgame", nil)
+	rec := httptest.NewRecorder()
+
+	h.AnalyzeEndgame(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestHint_Opening(t *testing.T) {
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{}, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.HintRequest{PlayID: "game-123", TimeLimitMS: 200}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/hint", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.Hint(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.HintResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SideToMove != "black" || resp.Exact {
+		t.Fatalf("expected a heuristic hint for black, got %+v", resp)
+	}
+	if len(resp.Moves) != 4 {
+		t.Fatalf("expected 4 moves, got %d", len(resp.Moves))
+	}
+	for _, m := range resp.Moves {
+		if len(m.Flips) != 1 {
+			t.Fatalf("expected 1 flip for an opening move, got %v", m.Flips)
+		}
+		if len(m.Line) == 0 || m.Line[0].Col != m.Col || m.Line[0].Row != m.Row || m.Line[0].Color != "black" {
+			t.Fatalf("expected line to start with the move, got %+v", m.Line)
+		}
+	}
+}
+
+func TestHint_ExactInEndgame(t *testing.T) {
+	moves := randomGameMoves("game-123", 3, 10)
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.HintRequest{PlayID: "game-123"}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/hint", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.Hint(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.HintResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Exact {
+		t.Fatal("expected an exact hint")
+	}
+
+	g, _ := replayGame(&model.Game{}, moves)
+	if len(resp.Moves) != len(g.Board.ValidMoves(g.Turn)) {
+		t.Fatalf("expected %d moves, got %d", len(g.Board.ValidMoves(g.Turn)), len(resp.Moves))
+	}
+	for i, m := range resp.Moves {
+		if i > 0 && m.Score > resp.Moves[i-1].Score {
+			t.Fatalf("expected moves sorted by score, got %+v", resp.Moves)
+		}
+	}
+}
+
+func TestHint_SolveTimesOut(t *testing.T) {
+	// a position that takes a few hundred milliseconds to solve
+	moves := randomGameMoves("game-123", 4, hintSolveEmpties)
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	}
+	h := New(mock)
+
+	// too little time to solve, but enough for a shallow search
+	body := model.HintRequest{PlayID: "game-123", TimeLimitMS: 100}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/hint", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.Hint(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.HintResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Exact || resp.Depth == 0 {
+		t.Fatalf("expected the heuristic search to run after the solve, got exact %v at depth %d", resp.Exact, resp.Depth)
+	}
+}
+
+func TestHint_MoveNumber(t *testing.T) {
+	moves := passGameMoves("game-123")
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	}
+	h := New(mock)
+
+	moveNumber := 1
+	body := model.HintRequest{PlayID: "game-123", MoveNumber: &moveNumber, TimeLimitMS: 100}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/hint", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.Hint(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.HintResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.MoveNumber != 1 || resp.SideToMove != "white" || len(resp.Moves) != 3 {
+		t.Fatalf("expected 3 white moves after move 1, got %+v", resp)
+	}
+}
+
+func TestHint_InvalidTimeLimit(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	body := model.HintRequest{PlayID: "game-123", TimeLimitMS: -1}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/hint", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.Hint(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestHint_MethodNotAllowed(t *testing.T) {
+	mock := &mockRepository{}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/hint", nil)
+	rec := httptest.NewRecorder()
+
+	h.Hint(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestPollMoves_Opening(t *testing.T) {
+	// Tiger: f5 d6 c3 d3 c4
+	seq := [][2]int{{5, 4}, {3, 5}, {2, 2}, {3, 2}, {2, 3}}
+	mock := &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			var moves []model.Move
+			for i, sq := range seq {
+				color := "black"
+				if i%2 == 1 {
+					color = "white"
+				}
+				moves = append(moves, model.Move{PlayID: playID, Color: color, Col: sq[0], Row: sq[1], MoveOrder: i + 1})
+			}
+			return moves, nil
+		},
+	}
+	h := New(mock)
+
+	body := model.PollMovesRequest{PlayID: "game-123", AfterMoveOrder: 3}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/poll-moves", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.PollMoves(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
 	var resp model.PollMovesResponse
 	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
-	if len(resp.Moves) != 0 {
-		t.Fatalf("expected 0 moves, got %d", len(resp.Moves))
+	if resp.Opening != "Tiger" {
+		t.Fatalf("expected opening Tiger, got %q", resp.Opening)
+	}
+	if len(resp.Moves) != 2 || resp.Moves[0].MoveOrder != 4 {
+		t.Fatalf("expected moves after move_order 3, got %+v", resp.Moves)
+	}
+}
+
+// GetGame tests
+
+func TestGetGame(t *testing.T) {
+	hostSecret, guestSecret := "host-secret", "guest-secret"
+	moves := append(passGameMoves("game-123"), model.Move{PlayID: "game-123", Color: "black", Col: -1, Row: -1, MoveOrder: 9, Pass: true})
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), hostSecret) || strings.Contains(rec.Body.String(), guestSecret) {
+		t.Fatal("expected secrets to be omitted")
+	}
+	var resp model.GameStateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SideToMove != "white" {
+		t.Fatalf("expected white to move after black's pass, got %q", resp.SideToMove)
+	}
+	if len(resp.LegalMoves) != 2 || resp.LegalMoves[0] != (model.Position{Col: 4, Row: 2}) || resp.LegalMoves[1] != (model.Position{Col: 5, Row: 5}) {
+		t.Fatalf("expected legal moves (4,2) and (5,5), got %+v", resp.LegalMoves)
+	}
+	if len(resp.Passes) != 1 || resp.Passes[0].MoveOrder != 9 {
+		t.Fatalf("expected one pass at move_order 9, got %+v", resp.Passes)
+	}
+	if resp.MoveCount != 9 || !resp.GuestJoined {
+		t.Fatalf("expected 9 moves with a guest, got %+v", resp)
+	}
+
+	g, _ := replayGame(&model.Game{}, moves)
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			want := ""
+			if c := g.Board.At(col, row); c != board.Empty {
+				want = c.String()
+			}
+			if resp.Board[row][col] != want {
+				t.Fatalf("expected %q at (%d,%d), got %q", want, col, row, resp.Board[row][col])
+			}
+		}
+	}
+	if resp.BlackCount != g.Board.Count(board.Black) || resp.WhiteCount != g.Board.Count(board.White) {
+		t.Fatalf("expected counts %d-%d, got %d-%d", g.Board.Count(board.Black), g.Board.Count(board.White), resp.BlackCount, resp.WhiteCount)
 	}
 }
 
-func TestPollMoves_MissingPlayID(t *testing.T) {
+func TestGetGame_Resigned(t *testing.T) {
+	result := "white_win"
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, Result: &result}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "black", Col: 2, Row: 3, MoveOrder: 1}}, nil
+		},
+	}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.GameStateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SideToMove != "" || len(resp.LegalMoves) != 0 {
+		t.Fatalf("expected nobody to move in a finished game, got %q with %d moves", resp.SideToMove, len(resp.LegalMoves))
+	}
+	if resp.Result == nil || *resp.Result != "white_win" {
+		t.Fatalf("expected result white_win, got %v", resp.Result)
+	}
+}
+
+func TestGetGame_NotFound(t *testing.T) {
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return nil, repository.ErrGameNotFound
+		},
+	}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/games/missing", nil)
+	req.SetPathValue("play_id", "missing")
+	rec := httptest.NewRecorder()
+
+	h.GetGame(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestGetGame_MethodNotAllowed(t *testing.T) {
 	mock := &mockRepository{}
 	h := New(mock)
 
-	body := model.PollMovesRequest{}
+	req := httptest.NewRequest(http.MethodPost, "/games/game-123", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetGame(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func pollRequest(t *testing.T, body model.PollMovesRequest) *http.Request {
+	t.Helper()
 	b, _ := json.Marshal(body)
-	req := httptest.NewRequest(http.MethodPost, "/poll-moves", bytes.NewReader(b))
+	return httptest.NewRequest(http.MethodPost, "/poll-moves", bytes.NewReader(b))
+}
+
+func TestPollMoves_WaitWakesOnMove(t *testing.T) {
+	h := New(liveRepository("host", "guest"))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123", WaitMS: 10000}))
+		done <- rec
+	}()
+	for h.hub.Subscribers("game-123") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	began := time.Now()
+	b, _ := json.Marshal(model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 3, Secret: "host"})
+	h.PlaceStone(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/place-stone", bytes.NewReader(b)))
+
+	rec := <-done
+	if time.Since(began) > 5*time.Second {
+		t.Fatal("expected the waiting poll to wake up on the move")
+	}
+	var resp model.PollMovesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Moves) != 1 || resp.Moves[0].MoveOrder != 1 {
+		t.Fatalf("expected move 1, got %+v", resp.Moves)
+	}
+}
+
+func TestPollMoves_WaitTimesOut(t *testing.T) {
+	h := New(&mockRepository{})
+
+	began := time.Now()
 	rec := httptest.NewRecorder()
+	h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123", WaitMS: 50}))
 
-	h.PollMoves(rec, req)
+	if elapsed := time.Since(began); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected to wait 50ms, returned after %v", elapsed)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if h.hub.Subscribers("game-123") != 0 {
+		t.Fatal("expected the subscription to be released")
+	}
+}
+
+func TestPollMoves_WaitCancelled(t *testing.T) {
+	h := New(&mockRepository{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	began := time.Now()
+	rec := httptest.NewRecorder()
+	h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123", WaitMS: 10000}).WithContext(ctx))
+
+	if time.Since(began) > time.Second {
+		t.Fatal("expected a cancelled request to return at once")
+	}
+}
+
+func TestPollMoves_WaitFinishedGame(t *testing.T) {
+	result := "draw"
+	h := New(&mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, Result: &result}, nil
+		},
+	})
+
+	began := time.Now()
+	h.PollMoves(httptest.NewRecorder(), pollRequest(t, model.PollMovesRequest{PlayID: "game-123", WaitMS: 10000}))
+
+	if time.Since(began) > time.Second {
+		t.Fatal("expected a finished game to return at once")
+	}
+}
+
+func TestPollMoves_TooManyWaiters(t *testing.T) {
+	h := New(&mockRepository{})
+	for i := 0; i < cap(h.waiters); i++ {
+		h.waiters <- struct{}{}
+	}
+
+	rec := httptest.NewRecorder()
+	h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123", WaitMS: 1000}))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", rec.Code)
+	}
+
+	// requests that don't wait are not limited
+	rec = httptest.NewRecorder()
+	h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+}
+
+func TestPollMoves_InvalidWait(t *testing.T) {
+	h := New(&mockRepository{})
+
+	rec := httptest.NewRecorder()
+	h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123", WaitMS: maxPollWaitMS + 1}))
 
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("expected status 400, got %d", rec.Code)
 	}
 }
 
-func TestPollMoves_MethodNotAllowed(t *testing.T) {
-	mock := &mockRepository{}
+func TestStartGame_Private(t *testing.T) {
+	var privatePlayID string
+	mock := &mockRepository{
+		setPrivateFn: func(playID string) error {
+			privatePlayID = playID
+			return nil
+		},
+	}
 	h := New(mock)
 
-	req := httptest.NewRequest(http.MethodGet, "/poll-moves", nil)
+	req := httptest.NewRequest(http.MethodPost, "/start-game", strings.NewReader(`{"private":true}`))
 	rec := httptest.NewRecorder()
 
-	h.PollMoves(rec, req)
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.StartGameResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if privatePlayID == "" || privatePlayID != resp.PlayID {
+		t.Fatalf("expected game %s to be made private, got %q", resp.PlayID, privatePlayID)
+	}
+}
+
+// whiteCornerPosition leaves white to move with only a1 to play, which
+// takes the whole first rank and ends the game.
+var whiteCornerPosition = "-XXXXXXO" + strings.Repeat("-", 56) + "O"
+
+func TestStartGame_Position(t *testing.T) {
+	var stored string
+	mock := &mockRepository{
+		setStartPositionFn: func(playID, position string) error {
+			stored = position
+			return nil
+		},
+	}
+	h := New(mock)
+
+	body := `{"position":"-oooooox ` + strings.Repeat("-------- ", 7) + `x"}`
+	req := httptest.NewRequest(http.MethodPost, "/start-game", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if want := "-OOOOOOX" + strings.Repeat("-", 56) + "X"; stored != want {
+		t.Fatalf("expected %s to be stored, got %q", want, stored)
+	}
+}
+
+func TestStartGame_PositionAIMovesFirst(t *testing.T) {
+	level := 1
+	var recorded []string
+	var result string
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, AILevel: &level, StartPosition: &whiteCornerPosition}, nil
+		},
+		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
+			recorded = append(recorded, fmt.Sprintf("%s %d,%d #%d", color, col, row, moveOrder))
+			return nil
+		},
+		endGameFn: func(playID string, blackCount, whiteCount int, res, termination string) error {
+			result = res
+			return nil
+		},
+	}
+	h := New(mock)
+
+	body, _ := json.Marshal(model.StartGameRequest{Opponent: "ai", Level: 1, Position: whiteCornerPosition})
+	req := httptest.NewRequest(http.MethodPost, "/start-game", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	h.StartGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(recorded) != 1 || recorded[0] != "white 0,0 #1" {
+		t.Fatalf("expected the AI to open with a1, got %v", recorded)
+	}
+	if result != "white_win" {
+		t.Fatalf("expected the game to end in white_win, got %q", result)
+	}
+}
+
+func TestStartGame_InvalidPosition(t *testing.T) {
+	for _, position := range []string{
+		"nonsense",
+		// black has no move and would have to pass at once
+		"-XXXXXXO" + strings.Repeat("-", 56) + "X",
+		// neither side can move
+		strings.Repeat("X", 64) + "-",
+	} {
+		mock := &mockRepository{
+			createGameWithSecretFn: func(playID, hostSecret string) error {
+				t.Fatalf("expected no game to be created for %s", position)
+				return nil
+			},
+		}
+		h := New(mock)
+
+		body, _ := json.Marshal(model.StartGameRequest{Position: position})
+		req := httptest.NewRequest(http.MethodPost, "/start-game", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.StartGame(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", position, rec.Code)
+		}
+	}
+}
+
+func TestGetGame_StartPosition(t *testing.T) {
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, StartPosition: &whiteCornerPosition}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "white", Col: 0, Row: 0, MoveOrder: 1}}, nil
+		},
+	}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.GameStateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.StartPosition == nil || *resp.StartPosition != whiteCornerPosition {
+		t.Fatalf("expected the start position, got %v", resp.StartPosition)
+	}
+	if resp.Board[0][7] != "white" || resp.WhiteCount != 8 || resp.BlackCount != 0 || resp.SideToMove != "" {
+		t.Fatalf("expected white to own the first rank, got %+v", resp)
+	}
+	if resp.Opening != "" {
+		t.Fatalf("expected no opening name, got %q", resp.Opening)
+	}
+}
+
+// privateRepository returns a private game with one spectator token.
+func privateRepository() *mockRepository {
+	hostSecret, guestSecret := "host-secret", "guest-secret"
+	return &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret, Private: true}, nil
+		},
+		isSpectatorFn: func(playID, token string) (bool, error) {
+			return token == "spectator-token", nil
+		},
+	}
+}
+
+func TestSpectate(t *testing.T) {
+	var addedPlayID, addedToken string
+	mock := &mockRepository{
+		addSpectatorFn: func(playID, token string) error {
+			addedPlayID, addedToken = playID, token
+			return nil
+		},
+	}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodPost, "/spectate", strings.NewReader(`{"play_id":"game-123"}`))
+	rec := httptest.NewRecorder()
+
+	h.Spectate(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.SpectateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SpectatorToken == "" || resp.SpectatorToken != addedToken {
+		t.Fatalf("expected the issued token to be stored, got %q and %q", resp.SpectatorToken, addedToken)
+	}
+	if addedPlayID != "game-123" {
+		t.Fatalf("expected playID game-123, got %s", addedPlayID)
+	}
+}
+
+func TestSpectate_PrivateGame(t *testing.T) {
+	tests := []struct {
+		secret string
+		want   int
+	}{
+		{"", http.StatusForbidden},
+		{"wrong", http.StatusForbidden},
+		{"host-secret", http.StatusOK},
+		{"guest-secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		h := New(privateRepository())
+
+		body := `{"play_id":"game-123","secret":"` + tt.secret + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/spectate", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.Spectate(rec, req)
+
+		if rec.Code != tt.want {
+			t.Fatalf("secret %q: expected status %d, got %d", tt.secret, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestSpectate_NotFound(t *testing.T) {
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return nil, repository.ErrGameNotFound
+		},
+	}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodPost, "/spectate", strings.NewReader(`{"play_id":"missing"}`))
+	rec := httptest.NewRecorder()
+
+	h.Spectate(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestSpectate_MethodNotAllowed(t *testing.T) {
+	h := New(&mockRepository{})
+
+	req := httptest.NewRequest(http.MethodGet, "/spectate", nil)
+	rec := httptest.NewRecorder()
+
+	h.Spectate(rec, req)
 
 	if rec.Code != http.StatusMethodNotAllowed {
 		t.Fatalf("expected status 405, got %d", rec.Code)
 	}
 }
+
+func TestGetGame_Private(t *testing.T) {
+	tests := []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusForbidden},
+		{"wrong", http.StatusForbidden},
+		{"spectator-token", http.StatusOK},
+		{"host-secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		h := New(privateRepository())
+
+		req := httptest.NewRequest(http.MethodGet, "/games/game-123?token="+tt.token, nil)
+		req.SetPathValue("play_id", "game-123")
+		rec := httptest.NewRecorder()
+
+		h.GetGame(rec, req)
+
+		if rec.Code != tt.want {
+			t.Fatalf("token %q: expected status %d, got %d", tt.token, tt.want, rec.Code)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var resp model.GameStateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if !resp.Private || resp.SpectatorCount != 0 {
+			t.Fatalf("expected a private game nobody is watching, got private=%v spectators=%d", resp.Private, resp.SpectatorCount)
+		}
+	}
+}
+
+func TestPollMoves_Private(t *testing.T) {
+	h := New(privateRepository())
+
+	rec := httptest.NewRecorder()
+	h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123"}))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403 without a token, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.PollMoves(rec, pollRequest(t, model.PollMovesRequest{PlayID: "game-123", Token: "spectator-token"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 with a spectator token, got %d", rec.Code)
+	}
+}
+
+func TestHint_Private(t *testing.T) {
+	h := New(privateRepository())
+
+	req := httptest.NewRequest(http.MethodPost, "/hint", strings.NewReader(`{"play_id":"game-123"}`))
+	rec := httptest.NewRecorder()
+
+	h.Hint(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", rec.Code)
+	}
+}
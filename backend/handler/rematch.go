@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/repository"
+)
+
+// Rematch offers a rematch of a finished game between humans, or accepts the
+// opponent's offer. Accepting creates the new game with the colors swapped:
+// the old guest hosts it and plays black. Once it exists, either player can
+// call Rematch again to get their new secret.
+func (h *Handler) Rematch(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.authorizePlayer(w, r, true)
+	if !ok {
+		return
+	}
+	if game.GuestSecret == nil {
+		respondError(w, http.StatusConflict, "there is no human opponent to offer a rematch to")
+		return
+	}
+
+	rematch, err := h.repo.GetRematch(game.PlayID)
+	if err == nil {
+		respondJSON(w, http.StatusOK, rematchResponse(rematch, color.Opponent()))
+		return
+	}
+	if !errors.Is(err, repository.ErrGameNotFound) {
+		respondError(w, http.StatusInternalServerError, "failed to get rematch")
+		return
+	}
+
+	if game.RematchOffer == nil {
+		offer := color.String()
+		if err := h.repo.SetRematchOffer(game.PlayID, &offer); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to offer rematch")
+			return
+		}
+		h.hub.Publish(model.GameEvent{Type: model.EventRematchOffer, PlayID: game.PlayID, Color: offer})
+		respondJSON(w, http.StatusOK, model.RematchResponse{Pending: true})
+		return
+	}
+	if *game.RematchOffer == color.String() {
+		respondError(w, http.StatusConflict, "rematch already offered")
+		return
+	}
+
+	playID := uuid.New().String()
+	hostSecret := uuid.New().String()
+	guestSecret := uuid.New().String()
+	if err := h.repo.CreateRematch(playID, game.PlayID, hostSecret, guestSecret); err != nil {
+		if errors.Is(err, repository.ErrNoRematchOffer) {
+			respondError(w, http.StatusConflict, "no rematch offer to accept")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to create rematch")
+		return
+	}
+	h.hub.Publish(model.GameEvent{Type: model.EventRematch, PlayID: game.PlayID, RematchPlayID: &playID})
+
+	rematch = &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret}
+	respondJSON(w, http.StatusOK, rematchResponse(rematch, color.Opponent()))
+}
+
+func (h *Handler) DeclineRematch(w http.ResponseWriter, r *http.Request) {
+	game, color, ok := h.authorizePlayer(w, r, true)
+	if !ok {
+		return
+	}
+	if game.RematchOffer == nil || *game.RematchOffer != color.Opponent().String() {
+		respondError(w, http.StatusConflict, "no rematch offer to decline")
+		return
+	}
+
+	if err := h.repo.SetRematchOffer(game.PlayID, nil); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to decline rematch")
+		return
+	}
+	h.hub.Publish(model.GameEvent{Type: model.EventRematchDecline, PlayID: game.PlayID, Color: color.String()})
+
+	respondJSON(w, http.StatusOK, model.SuccessResponse{Success: true})
+}
+
+// rematchResponse hands the player now playing c their side of the rematch.
+func rematchResponse(rematch *model.Game, c board.Color) model.RematchResponse {
+	secret := rematch.GuestSecret
+	if c == board.Black {
+		secret = rematch.HostSecret
+	}
+	resp := model.RematchResponse{PlayID: rematch.PlayID, Color: c.String()}
+	if secret != nil {
+		resp.Secret = *secret
+	}
+	return resp
+}
+
+// rematchPlayID returns the rematch of a game, or nil if there is none.
+func (h *Handler) rematchPlayID(game *model.Game) (*string, error) {
+	// a game gets its series once a rematch of it is created
+	if game.SeriesID == nil {
+		return nil, nil
+	}
+	rematch, err := h.repo.GetRematch(game.PlayID)
+	if errors.Is(err, repository.ErrGameNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rematch.PlayID, nil
+}
+
+// series returns the running score of the series a game belongs to, or nil
+// if it is not part of one.
+func (h *Handler) series(game *model.Game) (*model.Series, error) {
+	if game.SeriesID == nil {
+		return nil, nil
+	}
+	games, err := h.repo.GetSeries(*game.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+	return seriesScore(*game.SeriesID, game.PlayID, games), nil
+}
+
+// seriesScore follows the rematch chain from the first game of the series up
+// to playID and counts the results from the colors played in playID.
+func seriesScore(seriesID, playID string, games []model.Game) *model.Series {
+	next := make(map[string]*model.Game, len(games))
+	var g *model.Game
+	for i := range games {
+		if games[i].PlayID == seriesID {
+			g = &games[i]
+		} else if games[i].RematchOf != nil {
+			next[*games[i].RematchOf] = &games[i]
+		}
+	}
+
+	var chain []*model.Game
+	for ; g != nil; g = next[g.PlayID] {
+		chain = append(chain, g)
+		if g.PlayID == playID {
+			break
+		}
+	}
+
+	s := &model.Series{ID: seriesID, GameNumber: len(chain)}
+	for i, g := range chain {
+		if g.Result == nil {
+			continue
+		}
+		// colors swap every game
+		swapped := (len(chain)-1-i)%2 == 1
+		switch *g.Result {
+		case "draw":
+			s.Draws++
+		case "black_win":
+			if swapped {
+				s.White++
+			} else {
+				s.Black++
+			}
+		case "white_win":
+			if swapped {
+				s.Black++
+			} else {
+				s.White++
+			}
+		}
+	}
+	return s
+}
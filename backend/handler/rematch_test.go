@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/repository"
+)
+
+// rematchRepository returns a finished PvP game with the given pending
+// rematch offer and remembers the rematch once it is created.
+func rematchRepository(offer *string) (*mockRepository, *model.Game) {
+	hostSecret, guestSecret, result := "host-secret", "guest-secret", "black_win"
+	created := &model.Game{}
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret, Result: &result, RematchOffer: offer}, nil
+		},
+		setRematchOfferFn: func(playID string, color *string) error {
+			offer = color
+			return nil
+		},
+		createRematchFn: func(playID, previousPlayID, hostSecret, guestSecret string) error {
+			*created = model.Game{PlayID: playID, RematchOf: &previousPlayID, HostSecret: &hostSecret, GuestSecret: &guestSecret}
+			return nil
+		},
+		getRematchFn: func(playID string) (*model.Game, error) {
+			if created.PlayID == "" {
+				return nil, repository.ErrGameNotFound
+			}
+			return created, nil
+		},
+	}
+	return mock, created
+}
+
+func decodeRematch(t *testing.T, rec *httptest.ResponseRecorder) model.RematchResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp model.RematchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestRematch_Offer(t *testing.T) {
+	mock, _ := rematchRepository(nil)
+	h := New(mock)
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	resp := decodeRematch(t, gameAction(h.Rematch, "guest-secret"))
+
+	if !resp.Pending || resp.PlayID != "" {
+		t.Fatalf("expected a pending rematch, got %+v", resp)
+	}
+	ev := <-sub.Events()
+	if ev.Type != model.EventRematchOffer || ev.Color != "white" {
+		t.Fatalf("expected rematch_offered by white, got %+v", ev)
+	}
+}
+
+func TestRematch_AcceptSwapsColors(t *testing.T) {
+	offer := "white"
+	mock, created := rematchRepository(&offer)
+	h := New(mock)
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	// black accepts white's offer and plays white in the rematch
+	resp := decodeRematch(t, gameAction(h.Rematch, "host-secret"))
+
+	if created.RematchOf == nil || *created.RematchOf != "game-123" {
+		t.Fatalf("expected a rematch of game-123, got %+v", created)
+	}
+	if resp.Pending || resp.PlayID != created.PlayID || resp.Color != "white" || resp.Secret != *created.GuestSecret {
+		t.Fatalf("expected the guest side of the rematch, got %+v", resp)
+	}
+	ev := <-sub.Events()
+	if ev.Type != model.EventRematch || ev.RematchPlayID == nil || *ev.RematchPlayID != created.PlayID {
+		t.Fatalf("expected a rematch event, got %+v", ev)
+	}
+
+	// white comes back for the host side
+	resp = decodeRematch(t, gameAction(h.Rematch, "guest-secret"))
+	if resp.PlayID != created.PlayID || resp.Color != "black" || resp.Secret != *created.HostSecret {
+		t.Fatalf("expected the host side of the rematch, got %+v", resp)
+	}
+}
+
+func TestRematch_AlreadyOffered(t *testing.T) {
+	offer := "black"
+	mock, _ := rematchRepository(&offer)
+	h := New(mock)
+
+	rec := gameAction(h.Rematch, "host-secret")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestRematch_GameInProgress(t *testing.T) {
+	h := New(newActionRepository(nil, nil))
+
+	rec := gameAction(h.Rematch, "host-secret")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestDeclineRematch(t *testing.T) {
+	offer := "black"
+	mock, _ := rematchRepository(&offer)
+	var cleared bool
+	mock.setRematchOfferFn = func(playID string, color *string) error {
+		cleared = color == nil
+		return nil
+	}
+	h := New(mock)
+
+	if rec := gameAction(h.DeclineRematch, "host-secret"); rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409 declining an own offer, got %d", rec.Code)
+	}
+	if rec := gameAction(h.DeclineRematch, "guest-secret"); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !cleared {
+		t.Fatal("expected the offer to be cleared")
+	}
+}
+
+func TestSeriesScore(t *testing.T) {
+	first, second, third := "game-1", "game-2", "game-3"
+	blackWin, whiteWin, draw := "black_win", "white_win", "draw"
+	games := []model.Game{
+		{PlayID: third, RematchOf: &second},
+		{PlayID: first, Result: &blackWin},
+		{PlayID: second, RematchOf: &first, Result: &whiteWin},
+		{PlayID: "game-4", RematchOf: &third, Result: &draw},
+	}
+
+	// the host of game-1 won both games, as black then as white
+	s := seriesScore(first, second, games)
+	if s.GameNumber != 2 || s.White != 2 || s.Black != 0 || s.Draws != 0 {
+		t.Fatalf("expected game 2 with white on 2 wins, got %+v", s)
+	}
+	s = seriesScore(first, third, games)
+	if s.GameNumber != 3 || s.Black != 2 || s.White != 0 {
+		t.Fatalf("expected game 3 with black on 2 wins, got %+v", s)
+	}
+	s = seriesScore(first, "game-4", games)
+	if s.GameNumber != 4 || s.White != 2 || s.Draws != 1 {
+		t.Fatalf("expected game 4 with white on 2 wins and a draw, got %+v", s)
+	}
+}
+
+func TestGetGame_Series(t *testing.T) {
+	first, second, blackWin := "game-1", "game-123", "black_win"
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, RematchOf: &first, SeriesID: &first}, nil
+		},
+		getSeriesFn: func(seriesID string) ([]model.Game, error) {
+			return []model.Game{
+				{PlayID: first, SeriesID: &first, Result: &blackWin},
+				{PlayID: second, RematchOf: &first, SeriesID: &first},
+			}, nil
+		},
+	}
+	h := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.GameStateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Series == nil || resp.Series.ID != first || resp.Series.GameNumber != 2 || resp.Series.White != 1 {
+		t.Fatalf("expected game 2 of the series with white on 1 win, got %+v", resp.Series)
+	}
+	if resp.RematchPlayID != nil {
+		t.Fatalf("expected no rematch yet, got %s", *resp.RematchPlayID)
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"strconv"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/render"
+)
+
+// GetBoardSVG draws the board of a game as an SVG image.
+func (h *Handler) GetBoardSVG(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.boardScene(w, r)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := render.SVG(&buf, s); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to render board")
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(buf.Bytes())
+}
+
+// GetBoardPNG draws the board of a game as a PNG image.
+func (h *Handler) GetBoardPNG(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.boardScene(w, r)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, render.Image(s)); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to render board")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+// boardScene reads a board image request: move_number selects the position
+// (the latest by default), legal_moves=true dots the moves of the side to
+// move and token lets spectators see private games. On failure the error
+// response has already been written.
+func (h *Handler) boardScene(w http.ResponseWriter, r *http.Request) (render.Scene, bool) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return render.Scene{}, false
+	}
+
+	playID := r.PathValue("play_id")
+	if playID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return render.Scene{}, false
+	}
+
+	q := r.URL.Query()
+	var moveNumber *int
+	if v := q.Get("move_number"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "move_number must be an integer")
+			return render.Scene{}, false
+		}
+		moveNumber = &n
+	}
+	var legal bool
+	if v := q.Get("legal_moves"); v != "" {
+		var err error
+		if legal, err = strconv.ParseBool(v); err != nil {
+			respondError(w, http.StatusBadRequest, "legal_moves must be true or false")
+			return render.Scene{}, false
+		}
+	}
+
+	g, played, _, ok := h.loadPosition(w, playID, q.Get("token"), moveNumber)
+	if !ok {
+		return render.Scene{}, false
+	}
+	return render.Scene{Board: *g.Board, Turn: g.Turn, LastMove: lastPlaced(played), LegalMoves: legal}, true
+}
+
+// lastPlaced returns the square of the last stone placed, passes aside.
+func lastPlaced(moves []model.Move) *board.Position {
+	for i := len(moves) - 1; i >= 0; i-- {
+		if !moves[i].Pass {
+			return &board.Position{Col: moves[i].Col, Row: moves[i].Row}
+		}
+	}
+	return nil
+}
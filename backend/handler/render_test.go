@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/render"
+)
+
+// f5Repository returns a game in which black opened with f5.
+func f5Repository() *mockRepository {
+	return &mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{PlayID: playID, Color: "black", Col: 5, Row: 4, MoveOrder: 1}}, nil
+		},
+	}
+}
+
+func getBoard(h *Handler, handle func(*Handler, http.ResponseWriter, *http.Request), path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+	handle(h, rec, req)
+	return rec
+}
+
+func TestGetBoardSVG(t *testing.T) {
+	h := New(f5Repository())
+
+	rec := getBoard(h, (*Handler).GetBoardSVG, "/games/game-123/board.svg?legal_moves=true")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Fatalf("expected image/svg+xml, got %s", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `class="last-move"`) || strings.Count(body, `class="legal"`) != 3 {
+		t.Fatalf("expected the last move and white's 3 legal moves, got %s", body)
+	}
+}
+
+func TestGetBoardSVG_MoveNumber(t *testing.T) {
+	h := New(f5Repository())
+
+	rec := getBoard(h, (*Handler).GetBoardSVG, "/games/game-123/board.svg?move_number=0")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, `class="last-move"`) || strings.Contains(body, `class="legal"`) {
+		t.Fatalf("expected the starting position without markers, got %s", body)
+	}
+	if n := strings.Count(body, `fill="#111111"`); n != 2 {
+		t.Fatalf("expected 2 black discs, got %d", n)
+	}
+}
+
+func TestGetBoardSVG_InvalidQuery(t *testing.T) {
+	for _, query := range []string{"move_number=x", "move_number=2", "move_number=-1", "legal_moves=maybe"} {
+		h := New(f5Repository())
+
+		rec := getBoard(h, (*Handler).GetBoardSVG, "/games/game-123/board.svg?"+query)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestGetBoardSVG_Private(t *testing.T) {
+	h := New(privateRepository())
+
+	if rec := getBoard(h, (*Handler).GetBoardSVG, "/games/game-123/board.svg"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", rec.Code)
+	}
+	if rec := getBoard(h, (*Handler).GetBoardSVG, "/games/game-123/board.svg?token=spectator-token"); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 with a spectator token, got %d", rec.Code)
+	}
+}
+
+func TestGetBoardPNG(t *testing.T) {
+	h := New(f5Repository())
+
+	rec := getBoard(h, (*Handler).GetBoardPNG, "/games/game-123/board.png")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+		t.Fatalf("expected image/png, got %s", ct)
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != render.Size || b.Dy() != render.Size {
+		t.Fatalf("expected a %dx%d image, got %v", render.Size, render.Size, b)
+	}
+}
+
+func TestGetBoardPNG_MethodNotAllowed(t *testing.T) {
+	h := New(f5Repository())
+
+	req := httptest.NewRequest(http.MethodPost, "/games/game-123/board.png", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetBoardPNG(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/gif"
+	"net/http"
+	"strconv"
+
+	"github.com/dog-nose/othello-backend/ai"
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/render"
+)
+
+// Replay GIF settings: the frame delays accepted, how many delays the final
+// position stays up, and the search depth and the engine score filling the
+// evaluation bar.
+const (
+	defaultReplayDelayMS = 800
+	minReplayDelayMS     = 100
+	maxReplayDelayMS     = 10000
+	replayFinalHold      = 3
+	replayEvalDepth      = 4
+	replayEvalScale      = 200
+)
+
+// GetReplayGIF animates a finished game move by move for sharing. delay_ms
+// sets the time each move stays up and eval=true adds an evaluation bar
+// below the board; passes leave the board unchanged and get no frame.
+func (h *Handler) GetReplayGIF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	playID := r.PathValue("play_id")
+	if playID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+
+	q := r.URL.Query()
+	delayMS := defaultReplayDelayMS
+	if v := q.Get("delay_ms"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minReplayDelayMS || n > maxReplayDelayMS {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("delay_ms must be between %d and %d", minReplayDelayMS, maxReplayDelayMS))
+			return
+		}
+		delayMS = n
+	}
+	var eval bool
+	if v := q.Get("eval"); v != "" {
+		var err error
+		if eval, err = strconv.ParseBool(v); err != nil {
+			respondError(w, http.StatusBadRequest, "eval must be true or false")
+			return
+		}
+	}
+
+	game, status, msg := h.watchGame(playID, q.Get("token"))
+	if status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+	// the evaluation bar would otherwise be an engine aid
+	if game.Result == nil {
+		respondError(w, http.StatusConflict, "game is still in progress")
+		return
+	}
+	moves, err := h.repo.GetMovesAfter(playID, 0)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get moves")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), analysisTimeout)
+	defer cancel()
+
+	height := render.Size
+	if eval {
+		height += render.EvalBarHeight
+	}
+	anim := &gif.GIF{}
+	addFrame := func(g *board.Game, last *board.Position) {
+		img := image.NewPaletted(image.Rect(0, 0, render.Size, height), render.Palette)
+		render.Draw(img, image.Point{}, render.Scene{Board: *g.Board, Turn: g.Turn, LastMove: last})
+		if eval {
+			render.DrawEvalBar(img, image.Pt(0, render.Size), blackShare(ctx, g))
+		}
+		anim.Image = append(anim.Image, img)
+		// GIF delays are in hundredths of a second
+		anim.Delay = append(anim.Delay, delayMS/10)
+	}
+
+	g, err := startingGame(game)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to replay moves")
+		return
+	}
+	addFrame(g, nil)
+	for _, m := range moves {
+		if err := playMove(g, m); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to replay moves")
+			return
+		}
+		if !m.Pass {
+			addFrame(g, &board.Position{Col: m.Col, Row: m.Row})
+		}
+	}
+	anim.Delay[len(anim.Delay)-1] *= replayFinalHold
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to render replay")
+		return
+	}
+	w.Header().Set("Content-Type", "image/gif")
+	w.Write(buf.Bytes())
+}
+
+// blackShare is black's share of the evaluation bar: the share of the discs
+// once the game is over, otherwise a shallow search where replayEvalScale
+// engine units in black's favor fill the bar. The bar clamps larger scores.
+func blackShare(ctx context.Context, g *board.Game) float64 {
+	if g.IsOver() {
+		black, white := g.Board.Count(board.Black), g.Board.Count(board.White)
+		if black+white == 0 {
+			return 0.5
+		}
+		return float64(black) / float64(black+white)
+	}
+	score := ai.Search(ctx, *g.Board, g.Turn, replayEvalDepth).Score
+	if g.Turn == board.White {
+		score = -score
+	}
+	return 0.5 + float64(score)/(2*replayEvalScale)
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"image/gif"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/render"
+	"github.com/dog-nose/othello-backend/transcript"
+)
+
+// finishedRepository returns a finished game with the given moves.
+func finishedRepository(moves []model.Move) *mockRepository {
+	result := "white_win"
+	return &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, Result: &result}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	}
+}
+
+func getReplay(h *Handler, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123/replay.gif?"+query, nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+	h.GetReplayGIF(rec, req)
+	return rec
+}
+
+func TestGetReplayGIF(t *testing.T) {
+	moves := append(passGameMoves("game-123"), model.Move{PlayID: "game-123", Color: "black", Col: -1, Row: -1, MoveOrder: 9, Pass: true})
+	h := New(finishedRepository(moves))
+
+	rec := getReplay(h, "delay_ms=500")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/gif" {
+		t.Fatalf("expected image/gif, got %s", ct)
+	}
+	anim, err := gif.DecodeAll(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to decode GIF: %v", err)
+	}
+	// the start and one frame per stone placed; the pass adds none
+	if len(anim.Image) != 9 {
+		t.Fatalf("expected 9 frames, got %d", len(anim.Image))
+	}
+	if anim.Delay[0] != 50 || anim.Delay[8] != 50*replayFinalHold {
+		t.Fatalf("expected 50 and a held final frame, got %v", anim.Delay)
+	}
+	if b := anim.Image[0].Bounds(); b.Dx() != render.Size || b.Dy() != render.Size {
+		t.Fatalf("expected %dx%d frames, got %v", render.Size, render.Size, b)
+	}
+}
+
+func TestGetReplayGIF_Eval(t *testing.T) {
+	tm, _, err := transcript.Parse("e6f6f5d6e7f8f7f4c6b6")
+	if err != nil {
+		t.Fatalf("invalid transcript: %v", err)
+	}
+	moves := make([]model.Move, len(tm))
+	for i, m := range tm {
+		moves[i] = model.Move{Color: m.Color.String(), Col: m.Col, Row: m.Row, MoveOrder: i + 1, Pass: m.Pass}
+	}
+	h := New(finishedRepository(moves))
+
+	rec := getReplay(h, "eval=true")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	anim, err := gif.DecodeAll(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to decode GIF: %v", err)
+	}
+	if len(anim.Image) != 11 || anim.Delay[0] != defaultReplayDelayMS/10 {
+		t.Fatalf("expected 11 frames of the default delay, got %d with %v", len(anim.Image), anim.Delay)
+	}
+	last := anim.Image[len(anim.Image)-1]
+	if b := last.Bounds(); b.Dy() != render.Size+render.EvalBarHeight {
+		t.Fatalf("expected room for the evaluation bar, got %v", b)
+	}
+	// white wiped black out, so the bar is white all the way
+	if got := last.At(render.Margin+1, render.Size+render.EvalBarHeight/2); got != last.Palette[4] {
+		t.Fatalf("expected a white bar, got %v", got)
+	}
+}
+
+func TestGetReplayGIF_InProgress(t *testing.T) {
+	h := New(f5Repository())
+
+	if rec := getReplay(h, ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestGetReplayGIF_InvalidQuery(t *testing.T) {
+	for _, query := range []string{"delay_ms=x", "delay_ms=50", "delay_ms=20000", "eval=maybe"} {
+		h := New(finishedRepository(passGameMoves("game-123")))
+
+		if rec := getReplay(h, query); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestGetReplayGIF_Private(t *testing.T) {
+	h := New(privateRepository())
+
+	if rec := getReplay(h, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/hub"
+	"github.com/dog-nose/othello-backend/model"
+)
+
+const (
+	socketWriteWait  = 10 * time.Second
+	socketPongWait   = 60 * time.Second
+	socketPingPeriod = socketPongWait * 9 / 10
+)
+
+// upgrader accepts any origin, matching the CORS policy of the rest of the API.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// GameSocket streams the events of a game over a WebSocket. Clients pass
+// after_move_order, and the takebacks count their moves are from, to resume:
+// recorded moves after it are replayed first, then live events follow without
+// gaps or duplicates. Without takebacks the moves are taken to be from the
+// game's current count, so no takeback is replayed. Private games also require
+// a player secret or spectator token in the token parameter.
+func (h *Handler) GameSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	playID := r.PathValue("play_id")
+	if playID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+	after, takebacks, err := resumeCursor(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Subscribe before reading the history so nothing recorded in between is lost
+	sub := h.hub.Subscribe(playID)
+	defer sub.Close()
+
+	backlog, status, msg := h.backlog(sub, playID, r.URL.Query().Get("token"), after, takebacks)
+	if status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied to the client
+		return
+	}
+	defer conn.Close()
+
+	// The reader only serves control frames and notices when the client leaves
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	f := eventFilter{lastMoveOrder: after}
+	send := func(ev model.GameEvent) bool {
+		if !f.accept(ev) {
+			return true
+		}
+		conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+		return conn.WriteJSON(ev) == nil
+	}
+	for _, ev := range backlog {
+		if !send(ev) {
+			return
+		}
+	}
+
+	ping := time.NewTicker(socketPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too far behind; reconnect with after_move_order")
+				conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(socketWriteWait))
+				return
+			}
+			if !send(ev) {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait)); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// resumeCursor reads the after_move_order and takebacks query parameters a
+// client resumes from; takebacks is nil when left out.
+func resumeCursor(r *http.Request) (after int, takebacks *int, err error) {
+	if after, err = countParam(r, "after_move_order"); err != nil {
+		return 0, nil, err
+	}
+	if r.URL.Query().Get("takebacks") == "" {
+		return after, nil, nil
+	}
+	n, err := countParam(r, "takebacks")
+	if err != nil {
+		return 0, nil, err
+	}
+	return after, &n, nil
+}
+
+func countParam(r *http.Request, name string) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.New(name + " must be a non-negative integer")
+	}
+	return n, nil
+}
+
+// backlog rebuilds the events a subscriber resuming after a move_order has
+// missed. guest_joined, game_over, pending draw, takeback and rematch offers
+// and an accepted rematch describe state and are always included. When moves
+// the subscriber may have seen were taken back since the takebacks count it
+// passed, a takeback event rewinds its cursor first. Unless token belongs to
+// a player, sub is counted among the game's spectators.
+func (h *Handler) backlog(sub *hub.Subscription, playID, token string, after int, takebacks *int) ([]model.GameEvent, int, string) {
+	game, status, msg := h.watchGame(playID, token)
+	if status != http.StatusOK {
+		return nil, status, msg
+	}
+	if !isPlayer(game, token) {
+		sub.Spectate()
+	}
+	moves, err := h.repo.GetMovesAfter(playID, 0)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "failed to get moves"
+	}
+
+	var events []model.GameEvent
+	if game.GuestSecret != nil {
+		events = append(events, model.GameEvent{Type: model.EventGuestJoined, PlayID: playID})
+	}
+	after, rewound, err := h.takebackCursor(game, after, nextMoveOrder(moves)-1, takebacks)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "failed to get moves"
+	}
+	if rewound {
+		events = append(events, model.GameEvent{Type: model.EventTakeback, PlayID: playID, AfterMoveOrder: &after, Takebacks: &game.Takebacks})
+	}
+	for i := range moves {
+		if moves[i].MoveOrder > after {
+			events = append(events, moveEvent(playID, &moves[i]))
+		}
+	}
+	if game.Result != nil {
+		events = append(events, model.GameEvent{
+			Type:        model.EventGameOver,
+			PlayID:      playID,
+			Result:      game.Result,
+			Termination: game.Termination,
+			BlackCount:  game.BlackCount,
+			WhiteCount:  game.WhiteCount,
+		})
+		if game.RematchOffer != nil {
+			events = append(events, model.GameEvent{Type: model.EventRematchOffer, PlayID: playID, Color: *game.RematchOffer})
+		}
+		rematch, err := h.rematchPlayID(game)
+		if err != nil {
+			return nil, http.StatusInternalServerError, "failed to get rematch"
+		}
+		if rematch != nil {
+			events = append(events, model.GameEvent{Type: model.EventRematch, PlayID: playID, RematchPlayID: rematch})
+		}
+	} else {
+		if game.DrawOffer != nil {
+			events = append(events, model.GameEvent{Type: model.EventDrawOffer, PlayID: playID, Color: *game.DrawOffer})
+		}
+		if game.TakebackRequest != nil {
+			events = append(events, model.GameEvent{Type: model.EventTakebackRequest, PlayID: playID, Color: *game.TakebackRequest})
+		}
+	}
+	return events, http.StatusOK, ""
+}
+
+// eventFilter drops events a subscriber has already seen: moves at or before
+// the last delivered move_order and repeated state events. A takeback rewinds
+// the cursor; the other events are passed through as they come.
+type eventFilter struct {
+	lastMoveOrder int
+	joined, over  bool
+}
+
+func (f *eventFilter) accept(ev model.GameEvent) bool {
+	switch ev.Type {
+	case model.EventMove, model.EventPass:
+		if ev.Move.MoveOrder <= f.lastMoveOrder {
+			return false
+		}
+		f.lastMoveOrder = ev.Move.MoveOrder
+	case model.EventTakeback:
+		f.lastMoveOrder = *ev.AfterMoveOrder
+	case model.EventGuestJoined:
+		if f.joined {
+			return false
+		}
+		f.joined = true
+	case model.EventGameOver:
+		if f.over {
+			return false
+		}
+		f.over = true
+	}
+	return true
+}
+
+// takebackCursor returns where a client that saw the moves up to after, as of
+// the takebacks count given, should resume: before the first of them taken
+// back since, and never past last, the last of the game's moves. A nil count
+// stands for the game's current one. It reports whether the cursor moved.
+func (h *Handler) takebackCursor(game *model.Game, after, last int, takebacks *int) (int, bool, error) {
+	since := game.Takebacks
+	if takebacks != nil {
+		since = *takebacks
+	}
+	first, ok, err := h.repo.GetFirstRevertedMoveOrder(game.PlayID, after, since)
+	if err != nil {
+		return 0, false, err
+	}
+	cursor := after
+	if ok {
+		cursor = first - 1
+	}
+	if cursor > last {
+		cursor = last
+	}
+	return cursor, cursor != after, nil
+}
+
+func moveEvent(playID string, m *model.Move) model.GameEvent {
+	typ := model.EventMove
+	if m.Pass {
+		typ = model.EventPass
+	}
+	return model.GameEvent{Type: typ, PlayID: playID, Move: m}
+}
+
+func (h *Handler) publishMove(playID string, color board.Color, col, row, moveOrder int, pass bool) {
+	h.hub.Publish(moveEvent(playID, &model.Move{
+		PlayID:    playID,
+		Color:     color.String(),
+		Col:       col,
+		Row:       row,
+		MoveOrder: moveOrder,
+		Pass:      pass,
+		CreatedAt: time.Now(),
+	}))
+}
+
+func (h *Handler) publishGameOver(playID string, blackCount, whiteCount int, result, termination string) {
+	h.hub.Publish(model.GameEvent{
+		Type:        model.EventGameOver,
+		PlayID:      playID,
+		Result:      &result,
+		Termination: &termination,
+		BlackCount:  &blackCount,
+		WhiteCount:  &whiteCount,
+	})
+}
@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/repository"
+)
+
+// liveRepository is a mock backed by an in-memory move list, safe for use
+// from the goroutines of an httptest server.
+func liveRepository(hostSecret, guestSecret string) *mockRepository {
+	var mu sync.Mutex
+	var moves []model.Move
+	return &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret, GuestSecret: &guestSecret}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			var out []model.Move
+			for _, m := range moves {
+				if m.MoveOrder > afterMoveOrder {
+					out = append(out, m)
+				}
+			}
+			return out, nil
+		},
+		recordMoveFn: func(playID, color string, col, row, moveOrder int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			moves = append(moves, model.Move{PlayID: playID, Color: color, Col: col, Row: row, MoveOrder: moveOrder})
+			return nil
+		},
+	}
+}
+
+func newSocketServer(h *Handler) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/place-stone", h.PlaceStone)
+	mux.HandleFunc("/games/{play_id}/ws", h.GameSocket)
+	return httptest.NewServer(mux)
+}
+
+func dialSocket(t *testing.T, srv *httptest.Server, path string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+path, nil)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	return conn
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) model.GameEvent {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var ev model.GameEvent
+	if err := conn.ReadJSON(&ev); err != nil {
+		t.Fatalf("failed to read event: %v", err)
+	}
+	return ev
+}
+
+func placeStone(t *testing.T, srv *httptest.Server, req model.PlaceStoneRequest) {
+	t.Helper()
+	b, _ := json.Marshal(req)
+	res, err := http.Post(srv.URL+"/place-stone", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("failed to place stone: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+}
+
+func TestGameSocket_LiveMoves(t *testing.T) {
+	h := New(liveRepository("host", "guest"))
+	srv := newSocketServer(h)
+	defer srv.Close()
+
+	conn := dialSocket(t, srv, "/games/game-123/ws")
+	defer conn.Close()
+
+	if ev := readEvent(t, conn); ev.Type != model.EventGuestJoined {
+		t.Fatalf("expected guest_joined first, got %s", ev.Type)
+	}
+
+	placeStone(t, srv, model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 3, Secret: "host"})
+	ev := readEvent(t, conn)
+	if ev.Type != model.EventMove || ev.Move == nil || ev.Move.MoveOrder != 1 || ev.Move.Color != "black" {
+		t.Fatalf("expected black move 1, got %+v", ev)
+	}
+
+	placeStone(t, srv, model.PlaceStoneRequest{PlayID: "game-123", Color: "white", Col: 2, Row: 2, Secret: "guest"})
+	ev = readEvent(t, conn)
+	if ev.Type != model.EventMove || ev.Move.MoveOrder != 2 || ev.Move.Color != "white" {
+		t.Fatalf("expected white move 2, got %+v", ev)
+	}
+}
+
+func TestGameSocket_Resume(t *testing.T) {
+	h := New(liveRepository("host", "guest"))
+	srv := newSocketServer(h)
+	defer srv.Close()
+
+	placeStone(t, srv, model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 3, Secret: "host"})
+	placeStone(t, srv, model.PlaceStoneRequest{PlayID: "game-123", Color: "white", Col: 2, Row: 2, Secret: "guest"})
+
+	conn := dialSocket(t, srv, "/games/game-123/ws?after_move_order=1")
+	defer conn.Close()
+
+	readEvent(t, conn) // guest_joined
+	ev := readEvent(t, conn)
+	if ev.Type != model.EventMove || ev.Move.MoveOrder != 2 {
+		t.Fatalf("expected move 2 from the backlog, got %+v", ev)
+	}
+
+	placeStone(t, srv, model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 3, Row: 2, Secret: "host"})
+	ev = readEvent(t, conn)
+	if ev.Type != model.EventMove || ev.Move.MoveOrder != 3 {
+		t.Fatalf("expected live move 3, got %+v", ev)
+	}
+}
+
+func TestGameSocket_NotFound(t *testing.T) {
+	mock := &mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return nil, repository.ErrGameNotFound
+		},
+	}
+	srv := newSocketServer(New(mock))
+	defer srv.Close()
+
+	_, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/games/missing/ws", nil)
+	if err == nil {
+		t.Fatal("expected dial to fail")
+	}
+	if res == nil || res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %v", res)
+	}
+}
+
+func TestGameSocket_InvalidAfterMoveOrder(t *testing.T) {
+	h := New(&mockRepository{})
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123/ws?after_move_order=-1", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GameSocket(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	two := 2
+	f := eventFilter{lastMoveOrder: 2}
+	tests := []struct {
+		ev   model.GameEvent
+		want bool
+	}{
+		{model.GameEvent{Type: model.EventMove, Move: &model.Move{MoveOrder: 2}}, false},
+		{model.GameEvent{Type: model.EventMove, Move: &model.Move{MoveOrder: 3}}, true},
+		{model.GameEvent{Type: model.EventPass, Move: &model.Move{MoveOrder: 3}}, false},
+		{model.GameEvent{Type: model.EventGuestJoined}, true},
+		{model.GameEvent{Type: model.EventGuestJoined}, false},
+		{model.GameEvent{Type: model.EventTakeback, AfterMoveOrder: &two}, true},
+		{model.GameEvent{Type: model.EventMove, Move: &model.Move{MoveOrder: 3}}, true},
+		{model.GameEvent{Type: model.EventGameOver}, true},
+		{model.GameEvent{Type: model.EventGameOver}, false},
+	}
+	for i, tt := range tests {
+		if got := f.accept(tt.ev); got != tt.want {
+			t.Fatalf("event %d: expected %v, got %v", i, tt.want, got)
+		}
+	}
+}
+
+func TestJoinGame_PublishesGuestJoined(t *testing.T) {
+	h := New(&mockRepository{})
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	b, _ := json.Marshal(model.JoinGameRequest{PlayID: "game-123"})
+	req := httptest.NewRequest(http.MethodPost, "/join-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.JoinGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	select {
+	case ev := <-sub.Events():
+		if ev.Type != model.EventGuestJoined {
+			t.Fatalf("expected guest_joined, got %s", ev.Type)
+		}
+	default:
+		t.Fatal("expected an event")
+	}
+}
+
+func TestEndGame_PublishesGameOver(t *testing.T) {
+	hostSecret := "host"
+	h := New(&mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret}, nil
+		},
+	})
+	sub := h.hub.Subscribe("game-123")
+	defer sub.Close()
+
+	b, _ := json.Marshal(model.EndGameRequest{PlayID: "game-123", Secret: hostSecret})
+	req := httptest.NewRequest(http.MethodPost, "/end-game", bytes.NewReader(b))
+	rec := httptest.NewRecorder()
+
+	h.EndGame(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	select {
+	case ev := <-sub.Events():
+		if ev.Type != model.EventGameOver || ev.Result == nil || *ev.Result != "white_win" {
+			t.Fatalf("expected game_over with white_win, got %+v", ev)
+		}
+	default:
+		t.Fatal("expected an event")
+	}
+}
+
+func TestGameSocket_Private(t *testing.T) {
+	h := New(privateRepository())
+	srv := newSocketServer(h)
+	defer srv.Close()
+
+	_, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/games/game-123/ws", nil)
+	if err == nil {
+		t.Fatal("expected the dial to fail without a token")
+	}
+	if res == nil || res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %v", res)
+	}
+
+	conn := dialSocket(t, srv, "/games/game-123/ws?token=spectator-token")
+	conn.Close()
+}
+
+func TestGameSocket_Spectators(t *testing.T) {
+	h := New(privateRepository())
+	srv := newSocketServer(h)
+	defer srv.Close()
+
+	host := dialSocket(t, srv, "/games/game-123/ws?token=host-secret")
+	defer host.Close()
+	readEvent(t, host) // guest_joined
+	spectator := dialSocket(t, srv, "/games/game-123/ws?token=spectator-token")
+	readEvent(t, spectator) // guest_joined
+
+	if n := h.hub.Spectators("game-123"); n != 1 {
+		t.Fatalf("expected 1 spectator, got %d", n)
+	}
+
+	spectator.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for h.hub.Spectators("game-123") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the spectator to leave")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := h.hub.Subscribers("game-123"); n != 1 {
+		t.Fatalf("expected the host to stay subscribed, got %d subscribers", n)
+	}
+}
+
+func TestGameSocket_ResumeAfterTakeback(t *testing.T) {
+	repo := liveRepository("host", "guest")
+	repo.getFirstRevertedFn = func(playID string, upTo, sinceTakebacks int) (int, bool, error) {
+		return 2, true, nil
+	}
+	h := New(repo)
+	srv := newSocketServer(h)
+	defer srv.Close()
+
+	placeStone(t, srv, model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 3, Secret: "host"})
+
+	// the client last saw move 3 before moves 2 and 3 were taken back
+	conn := dialSocket(t, srv, "/games/game-123/ws?after_move_order=3&takebacks=0")
+	defer conn.Close()
+
+	if ev := readEvent(t, conn); ev.Type != model.EventGuestJoined {
+		t.Fatalf("expected guest_joined, got %s", ev.Type)
+	}
+	ev := readEvent(t, conn)
+	if ev.Type != model.EventTakeback || ev.AfterMoveOrder == nil || *ev.AfterMoveOrder != 1 {
+		t.Fatalf("expected takeback to move 1, got %+v", ev)
+	}
+
+	placeStone(t, srv, model.PlaceStoneRequest{PlayID: "game-123", Color: "white", Col: 2, Row: 2, Secret: "guest"})
+	if ev := readEvent(t, conn); ev.Type != model.EventMove || ev.Move.MoveOrder != 2 {
+		t.Fatalf("expected move 2 after the takeback, got %+v", ev)
+	}
+}
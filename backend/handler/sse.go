@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dog-nose/othello-backend/model"
+)
+
+// sseHeartbeat keeps idle streams alive through proxies.
+const sseHeartbeat = 30 * time.Second
+
+// GameEvents streams the events of a game as Server-Sent Events. Move and pass
+// events carry their move_order as the event id and takebacks the move_order
+// they rewind to, so a reconnecting EventSource resumes through Last-Event-ID;
+// new clients may pass after_move_order instead. The takebacks parameter is
+// kept across reconnects, so a stream resumed after a takeback is rewound
+// to before the moves taken back and replays what follows; as for GameSocket,
+// leaving it out means the game's current count.
+func (h *Handler) GameEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	playID := r.PathValue("play_id")
+	if playID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+	after, takebacks, err := resumeCursor(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		n, err := strconv.Atoi(id)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "Last-Event-ID must be a move_order")
+			return
+		}
+		after = n
+	}
+
+	sub := h.hub.Subscribe(playID)
+	defer sub.Close()
+
+	backlog, status, msg := h.backlog(sub, playID, r.URL.Query().Get("token"), after, takebacks)
+	if status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// stop nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	f := eventFilter{lastMoveOrder: after}
+	send := func(ev model.GameEvent) bool {
+		if !f.accept(ev) {
+			return true
+		}
+		if err := writeSSE(w, ev); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	for _, ev := range backlog {
+		if !send(ev) {
+			return
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				// dropped for falling behind; EventSource reconnects with Last-Event-ID
+				return
+			}
+			if !send(ev) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// eventID is the move_order a client has caught up to after ev, if ev moves it.
+func eventID(ev model.GameEvent) (int, bool) {
+	switch {
+	case ev.Move != nil:
+		return ev.Move.MoveOrder, true
+	case ev.AfterMoveOrder != nil:
+		return *ev.AfterMoveOrder, true
+	}
+	return 0, false
+}
+
+func writeSSE(w http.ResponseWriter, ev model.GameEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if id, ok := eventID(ev); ok {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dog-nose/othello-backend/model"
+)
+
+type sseEvent struct {
+	id, event string
+	data      model.GameEvent
+}
+
+func newEventServer(h *Handler) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/place-stone", h.PlaceStone)
+	mux.HandleFunc("/chat", h.Chat)
+	mux.HandleFunc("/games/{play_id}/events", h.GameEvents)
+	return httptest.NewServer(mux)
+}
+
+// openStream connects to an event stream and returns its lines.
+func openStream(t *testing.T, srv *httptest.Server, path, lastEventID string) (*http.Response, <-chan string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %s", ct)
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(res.Body)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+	return res, lines
+}
+
+func readSSE(t *testing.T, lines <-chan string) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			switch {
+			case line == "" && ev.event != "":
+				return ev
+			case strings.HasPrefix(line, "id: "):
+				ev.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				ev.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data); err != nil {
+					t.Fatalf("failed to decode data: %v", err)
+				}
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+}
+
+func post(t *testing.T, srv *httptest.Server, path string, body interface{}) int {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	res, err := http.Post(srv.URL+path, "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("failed to post %s: %v", path, err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
+
+func TestGameEvents_LiveEvents(t *testing.T) {
+	h := New(liveRepository("host", "guest"))
+	srv := newEventServer(h)
+	defer srv.Close()
+
+	res, r := openStream(t, srv, "/games/game-123/events", "")
+	defer res.Body.Close()
+
+	if ev := readSSE(t, r); ev.event != model.EventGuestJoined {
+		t.Fatalf("expected guest_joined first, got %s", ev.event)
+	}
+
+	if code := post(t, srv, "/place-stone", model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 3, Secret: "host"}); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	ev := readSSE(t, r)
+	if ev.event != model.EventMove || ev.id != "1" || ev.data.Move.Color != "black" {
+		t.Fatalf("expected move with id 1, got %+v", ev)
+	}
+
+	if code := post(t, srv, "/chat", model.ChatRequest{PlayID: "game-123", Secret: "guest", Message: "good move"}); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	ev = readSSE(t, r)
+	if ev.event != model.EventChat || ev.id != "" || ev.data.Chat.Color != "white" || ev.data.Chat.Text != "good move" {
+		t.Fatalf("expected chat from white without an id, got %+v", ev)
+	}
+}
+
+func TestGameEvents_LastEventID(t *testing.T) {
+	h := New(liveRepository("host", "guest"))
+	srv := newEventServer(h)
+	defer srv.Close()
+
+	post(t, srv, "/place-stone", model.PlaceStoneRequest{PlayID: "game-123", Color: "black", Col: 2, Row: 3, Secret: "host"})
+	post(t, srv, "/place-stone", model.PlaceStoneRequest{PlayID: "game-123", Color: "white", Col: 2, Row: 2, Secret: "guest"})
+
+	res, r := openStream(t, srv, "/games/game-123/events?after_move_order=0", "1")
+	defer res.Body.Close()
+
+	readSSE(t, r) // guest_joined
+	if ev := readSSE(t, r); ev.id != "2" || ev.data.Move.MoveOrder != 2 {
+		t.Fatalf("expected to resume at move 2, got %+v", ev)
+	}
+}
+
+func TestGameEvents_InvalidLastEventID(t *testing.T) {
+	h := New(&mockRepository{})
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123/events", nil)
+	req.SetPathValue("play_id", "game-123")
+	req.Header.Set("Last-Event-ID", "abc")
+	rec := httptest.NewRecorder()
+
+	h.GameEvents(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestChat_InvalidSecret(t *testing.T) {
+	hostSecret := "host"
+	h := New(&mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, HostSecret: &hostSecret}, nil
+		},
+	})
+
+	b, _ := json.Marshal(model.ChatRequest{PlayID: "game-123", Secret: "spectator", Message: "hi"})
+	rec := httptest.NewRecorder()
+	h.Chat(rec, httptest.NewRequest(http.MethodPost, "/chat", bytes.NewReader(b)))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", rec.Code)
+	}
+}
+
+func TestChat_EmptyMessage(t *testing.T) {
+	h := New(&mockRepository{})
+
+	b, _ := json.Marshal(model.ChatRequest{PlayID: "game-123", Secret: "host", Message: "   "})
+	rec := httptest.NewRecorder()
+	h.Chat(rec, httptest.NewRequest(http.MethodPost, "/chat", bytes.NewReader(b)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rec.Code)
+	}
+}
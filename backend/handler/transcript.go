@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/model"
+	"github.com/dog-nose/othello-backend/transcript"
+)
+
+// GetTranscript exports a game as a standard transcript such as "f5d6c3d3c4".
+func (h *Handler) GetTranscript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	playID := r.PathValue("play_id")
+	if playID == "" {
+		respondError(w, http.StatusBadRequest, "play_id is required")
+		return
+	}
+
+	game, status, msg := h.watchGame(playID, r.URL.Query().Get("token"))
+	if status != http.StatusOK {
+		respondError(w, status, msg)
+		return
+	}
+	moves, err := h.repo.GetMovesAfter(playID, 0)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get moves")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, model.TranscriptResponse{PlayID: playID, StartPosition: game.StartPosition, Transcript: transcript.Format(transcriptMoves(moves))})
+}
+
+// ImportGame creates a game from a transcript. Every move is checked against
+// the rules and the passes are recorded where they fall. A transcript that
+// plays the game out creates a finished game; otherwise it can be played on
+// with the returned host secret.
+func (h *Handler) ImportGame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req model.ImportGameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.Transcript) == "" {
+		respondError(w, http.StatusBadRequest, "transcript is required")
+		return
+	}
+	tm, g, err := transcript.Parse(req.Transcript)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid transcript: "+err.Error())
+		return
+	}
+
+	game := &model.Game{PlayID: uuid.New().String()}
+	if g.IsOver() {
+		blackCount, whiteCount := g.Board.Count(board.Black), g.Board.Count(board.White)
+		result, termination := board.Result(blackCount, whiteCount), model.TerminationNormal
+		game.BlackCount, game.WhiteCount = &blackCount, &whiteCount
+		game.Result, game.Termination = &result, &termination
+	} else {
+		hostSecret := uuid.New().String()
+		game.HostSecret = &hostSecret
+	}
+	moves := make([]model.Move, len(tm))
+	for i, m := range tm {
+		moves[i] = model.Move{PlayID: game.PlayID, Color: m.Color.String(), Col: m.Col, Row: m.Row, MoveOrder: i + 1, Pass: m.Pass}
+	}
+	if err := h.repo.ImportGame(game, moves); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to import game")
+		return
+	}
+
+	resp := model.ImportGameResponse{PlayID: game.PlayID, Result: game.Result}
+	if game.HostSecret != nil {
+		resp.HostSecret = *game.HostSecret
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func transcriptMoves(moves []model.Move) []transcript.Move {
+	tm := make([]transcript.Move, len(moves))
+	for i, m := range moves {
+		color, _ := board.ParseColor(m.Color)
+		tm[i] = transcript.Move{Color: color, Col: m.Col, Row: m.Row, Pass: m.Pass}
+	}
+	return tm
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/model"
+)
+
+func importGame(h *Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/import-game", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ImportGame(rec, req)
+	return rec
+}
+
+func TestImportGame_InProgress(t *testing.T) {
+	var imported *model.Game
+	var moves []model.Move
+	h := New(&mockRepository{
+		importGameFn: func(game *model.Game, m []model.Move) error {
+			imported, moves = game, m
+			return nil
+		},
+	})
+
+	rec := importGame(h, `{"transcript":"f5d6c3"}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp model.ImportGameResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.HostSecret == "" || imported.HostSecret == nil || *imported.HostSecret != resp.HostSecret {
+		t.Fatalf("expected the host secret of the imported game, got %+v", resp)
+	}
+	if resp.Result != nil || imported.Result != nil {
+		t.Fatal("expected the game to be in progress")
+	}
+	if len(moves) != 3 || moves[2].MoveOrder != 3 || moves[2].Color != "black" || moves[2].Col != 2 || moves[2].Row != 2 {
+		t.Fatalf("expected black c3 as move 3, got %+v", moves)
+	}
+}
+
+func TestImportGame_Finished(t *testing.T) {
+	var imported *model.Game
+	h := New(&mockRepository{
+		importGameFn: func(game *model.Game, m []model.Move) error {
+			imported = game
+			return nil
+		},
+	})
+
+	rec := importGame(h, `{"transcript":"e6f6f5d6e7f8f7f4c6b6"}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if imported.Result == nil || *imported.Result != "white_win" || *imported.Termination != model.TerminationNormal {
+		t.Fatalf("expected white_win, got %+v", imported)
+	}
+	if *imported.BlackCount != 0 || *imported.WhiteCount != 14 {
+		t.Fatalf("expected 0-14, got %d-%d", *imported.BlackCount, *imported.WhiteCount)
+	}
+	if imported.HostSecret != nil {
+		t.Fatal("expected no host secret for a finished game")
+	}
+}
+
+func TestImportGame_Invalid(t *testing.T) {
+	for _, body := range []string{`{}`, `{"transcript":"f5f5"}`, `{"transcript":"f5d"}`, `not json`} {
+		h := New(&mockRepository{
+			importGameFn: func(game *model.Game, m []model.Move) error {
+				t.Fatalf("expected nothing to be imported for %s", body)
+				return nil
+			},
+		})
+
+		if rec := importGame(h, body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestGetTranscript(t *testing.T) {
+	moves := []model.Move{
+		{Color: "black", Col: 5, Row: 4, MoveOrder: 1},
+		{Color: "white", Col: 3, Row: 5, MoveOrder: 2},
+		{Color: "black", Col: -1, Row: -1, MoveOrder: 3, Pass: true},
+		{Color: "white", Col: 2, Row: 2, MoveOrder: 4},
+	}
+	h := New(&mockRepository{
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return moves, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123/transcript", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetTranscript(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.TranscriptResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Transcript != "f5d6c3" {
+		t.Fatalf("expected f5d6c3, got %s", resp.Transcript)
+	}
+}
+
+func TestGetTranscript_Private(t *testing.T) {
+	h := New(privateRepository())
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123/transcript", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetTranscript(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", rec.Code)
+	}
+}
+
+func TestGetGGF(t *testing.T) {
+	player := "alice"
+	h := New(&mockRepository{
+		getGameFn: func(playID string) (*model.Game, error) {
+			return &model.Game{PlayID: playID, BlackPlayer: &player}, nil
+		},
+		getMovesAfterFn: func(playID string, afterMoveOrder int) ([]model.Move, error) {
+			return []model.Move{{Color: "black", Col: 5, Row: 4, MoveOrder: 1}}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/games/game-123/ggf", nil)
+	req.SetPathValue("play_id", "game-123")
+	rec := httptest.NewRecorder()
+
+	h.GetGGF(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp model.GGFResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(resp.GGF, "(;GM[Othello]") || !strings.Contains(resp.GGF, "PB[alice]") || !strings.Contains(resp.GGF, "B[f5]") {
+		t.Fatalf("expected a GGF record by alice starting with f5, got %s", resp.GGF)
+	}
+}
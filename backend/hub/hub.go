@@ -0,0 +1,108 @@
+package hub
+
+import (
+	"sync"
+
+	"github.com/dog-nose/othello-backend/model"
+)
+
+// bufferSize is how many events a subscriber may lag behind before it is dropped.
+const bufferSize = 64
+
+// Hub fans game events out to in-process subscribers, keyed by play_id.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+func New() *Hub {
+	return &Hub{subs: map[string]map[*Subscription]struct{}{}}
+}
+
+// Subscription receives the events of one game. Its channel is closed when
+// Close is called or when the subscriber falls too far behind, in which case
+// the client is expected to reconnect and resume from its last move_order.
+type Subscription struct {
+	hub       *Hub
+	playID    string
+	ch        chan model.GameEvent
+	spectator bool
+}
+
+func (h *Hub) Subscribe(playID string) *Subscription {
+	s := &Subscription{hub: h, playID: playID, ch: make(chan model.GameEvent, bufferSize)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[playID] == nil {
+		h.subs[playID] = map[*Subscription]struct{}{}
+	}
+	h.subs[playID][s] = struct{}{}
+	return s
+}
+
+// Publish delivers ev to every subscriber of ev.PlayID without blocking.
+func (h *Hub) Publish(ev model.GameEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs[ev.PlayID] {
+		select {
+		case s.ch <- ev:
+		default:
+			h.remove(s)
+		}
+	}
+}
+
+// Subscribers returns the number of live subscriptions to a game.
+func (h *Hub) Subscribers(playID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[playID])
+}
+
+// Spectators returns the number of live subscriptions to a game marked with
+// Spectate.
+func (h *Hub) Spectators(playID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for s := range h.subs[playID] {
+		if s.spectator {
+			n++
+		}
+	}
+	return n
+}
+
+// Spectate counts s among the spectators of its game until it is closed.
+func (s *Subscription) Spectate() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.spectator = true
+}
+
+func (s *Subscription) Events() <-chan model.GameEvent {
+	return s.ch
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove must be called with h.mu held; it is a no-op for removed subscriptions.
+func (h *Hub) remove(s *Subscription) {
+	subs, ok := h.subs[s.playID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	close(s.ch)
+	if len(subs) == 0 {
+		delete(h.subs, s.playID)
+	}
+}
@@ -0,0 +1,82 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/dog-nose/othello-backend/model"
+)
+
+func TestPublish(t *testing.T) {
+	h := New()
+	a := h.Subscribe("game-1")
+	b := h.Subscribe("game-1")
+	other := h.Subscribe("game-2")
+
+	h.Publish(model.GameEvent{Type: model.EventGuestJoined, PlayID: "game-1"})
+
+	for _, s := range []*Subscription{a, b} {
+		select {
+		case ev := <-s.Events():
+			if ev.Type != model.EventGuestJoined {
+				t.Fatalf("expected guest_joined, got %s", ev.Type)
+			}
+		default:
+			t.Fatal("expected an event")
+		}
+	}
+	select {
+	case ev := <-other.Events():
+		t.Fatalf("expected no event for another game, got %+v", ev)
+	default:
+	}
+}
+
+func TestClose(t *testing.T) {
+	h := New()
+	s := h.Subscribe("game-1")
+	s.Close()
+	s.Close()
+
+	if _, ok := <-s.Events(); ok {
+		t.Fatal("expected closed channel")
+	}
+	if n := h.Subscribers("game-1"); n != 0 {
+		t.Fatalf("expected 0 subscribers, got %d", n)
+	}
+	h.Publish(model.GameEvent{Type: model.EventMove, PlayID: "game-1"})
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	h := New()
+	s := h.Subscribe("game-1")
+	for i := 0; i <= bufferSize; i++ {
+		h.Publish(model.GameEvent{Type: model.EventMove, PlayID: "game-1", Move: &model.Move{MoveOrder: i + 1}})
+	}
+
+	n := 0
+	for range s.Events() {
+		n++
+	}
+	if n != bufferSize {
+		t.Fatalf("expected %d buffered events before the drop, got %d", bufferSize, n)
+	}
+	if h.Subscribers("game-1") != 0 {
+		t.Fatal("expected the slow subscriber to be removed")
+	}
+}
+
+func TestSpectators(t *testing.T) {
+	h := New()
+	h.Subscribe("game-1")
+	s := h.Subscribe("game-1")
+	s.Spectate()
+	h.Subscribe("game-2").Spectate()
+
+	if n := h.Spectators("game-1"); n != 1 {
+		t.Fatalf("expected 1 spectator, got %d", n)
+	}
+	s.Close()
+	if n := h.Spectators("game-1"); n != 0 {
+		t.Fatalf("expected 0 spectators, got %d", n)
+	}
+}
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 
+	"github.com/dog-nose/othello-backend/book"
 	"github.com/dog-nose/othello-backend/config"
 	"github.com/dog-nose/othello-backend/handler"
 	"github.com/dog-nose/othello-backend/middleware"
@@ -30,13 +33,54 @@ func main() {
 
 	repo := repository.NewMySQLRepository(db)
 	h := handler.New(repo)
+	if cfg.OpeningBook != "" {
+		bk, err := book.Load(cfg.OpeningBook)
+		if err != nil {
+			log.Fatalf("failed to load opening book: %v", err)
+		}
+		h.SetBook(bk)
+	}
+	go h.SweepClocks(context.Background(), time.Second)
+	if cfg.AbandonAfter > 0 {
+		if cfg.AbandonedCleanup != "archive" && cfg.AbandonedCleanup != "purge" {
+			log.Fatalf("ABANDONED_CLEANUP must be 'archive' or 'purge', got %q", cfg.AbandonedCleanup)
+		}
+		go h.SweepAbandoned(context.Background(), time.Minute, handler.CleanupPolicy{
+			InactiveAfter: cfg.AbandonAfter,
+			RetainFor:     cfg.AbandonedRetention,
+			Archive:       cfg.AbandonedCleanup == "archive",
+		})
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/start-game", h.StartGame)
 	mux.HandleFunc("/place-stone", h.PlaceStone)
 	mux.HandleFunc("/end-game", h.EndGame)
+	mux.HandleFunc("/resign", h.EndGame)
+	mux.HandleFunc("/offer-draw", h.OfferDraw)
+	mux.HandleFunc("/accept-draw", h.AcceptDraw)
+	mux.HandleFunc("/decline-draw", h.DeclineDraw)
+	mux.HandleFunc("/abort-game", h.AbortGame)
+	mux.HandleFunc("/request-takeback", h.RequestTakeback)
+	mux.HandleFunc("/accept-takeback", h.AcceptTakeback)
+	mux.HandleFunc("/decline-takeback", h.DeclineTakeback)
+	mux.HandleFunc("/rematch", h.Rematch)
+	mux.HandleFunc("/decline-rematch", h.DeclineRematch)
 	mux.HandleFunc("/join-game", h.JoinGame)
+	mux.HandleFunc("/spectate", h.Spectate)
 	mux.HandleFunc("/poll-moves", h.PollMoves)
+	mux.HandleFunc("/analyze-endgame", h.AnalyzeEndgame)
+	mux.HandleFunc("/hint", h.Hint)
+	mux.HandleFunc("/games/{play_id}", h.GetGame)
+	mux.HandleFunc("/games/{play_id}/ws", h.GameSocket)
+	mux.HandleFunc("/games/{play_id}/events", h.GameEvents)
+	mux.HandleFunc("/games/{play_id}/transcript", h.GetTranscript)
+	mux.HandleFunc("/games/{play_id}/ggf", h.GetGGF)
+	mux.HandleFunc("/games/{play_id}/board.svg", h.GetBoardSVG)
+	mux.HandleFunc("/games/{play_id}/board.png", h.GetBoardPNG)
+	mux.HandleFunc("/games/{play_id}/replay.gif", h.GetReplayGIF)
+	mux.HandleFunc("/import-game", h.ImportGame)
+	mux.HandleFunc("/chat", h.Chat)
 
 	server := middleware.CORS(mux)
 
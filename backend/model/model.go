@@ -5,14 +5,126 @@ import "time"
 // Domain types
 
 type Game struct {
-	PlayID      string    `json:"play_id"`
-	BlackCount  *int      `json:"black_count"`
-	WhiteCount  *int      `json:"white_count"`
-	Result      *string   `json:"result"`
-	HostSecret  *string   `json:"host_secret,omitempty"`
-	GuestSecret *string   `json:"guest_secret,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	PlayID        string  `json:"play_id"`
+	BlackCount    *int    `json:"black_count"`
+	WhiteCount    *int    `json:"white_count"`
+	Result        *string `json:"result"`
+	HostSecret    *string `json:"host_secret,omitempty"`
+	GuestSecret   *string `json:"guest_secret,omitempty"`
+	AILevel       *int    `json:"ai_level"`
+	AIEngine      *string `json:"ai_engine"`
+	AIPlayouts    *int    `json:"ai_playouts,omitempty"`
+	AITimeLimitMS *int    `json:"ai_time_limit_ms,omitempty"`
+	Private       bool    `json:"private"`
+	// Termination says how a finished game ended; DrawOffer and
+	// TakebackRequest are the color of a player whose offer or request is pending.
+	Termination     *string `json:"termination"`
+	DrawOffer       *string `json:"draw_offer"`
+	TakebackRequest *string `json:"takeback_request"`
+	// Takebacks counts the takebacks accepted so far; clients resuming
+	// after a move_order pass it so that a takeback they missed is noticed.
+	Takebacks int `json:"takebacks"`
+	// Clock settings are nil for untimed games.
+	ClockBaseMS      *int `json:"clock_base_ms,omitempty"`
+	ClockIncrementMS *int `json:"clock_increment_ms,omitempty"`
+	ClockMoveMS      *int `json:"clock_move_ms,omitempty"`
+	// ClockResumedAt is when the clocks last started: when the guest joined,
+	// or after the last takeback.
+	ClockResumedAt *time.Time `json:"clock_resumed_at,omitempty"`
+	// RematchOf is the game this one is a rematch of. SeriesID is the play_id
+	// of the first game of a series, set on every game in it.
+	RematchOf    *string `json:"rematch_of,omitempty"`
+	SeriesID     *string `json:"series_id,omitempty"`
+	RematchOffer *string `json:"rematch_offer"`
+	// Players, ratings and the date played come from imported records.
+	BlackPlayer *string    `json:"black_player,omitempty"`
+	WhitePlayer *string    `json:"white_player,omitempty"`
+	BlackRating *float64   `json:"black_rating,omitempty"`
+	WhiteRating *float64   `json:"white_rating,omitempty"`
+	PlayedAt    *time.Time `json:"played_at,omitempty"`
+	// StartPosition is the position string the game started from, nil for
+	// the standard setup.
+	StartPosition *string   `json:"start_position,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ReferenceGame is an expert game from the WTHOR database, kept apart from
+// the games played here. Source and SourceIndex locate it in the file it was
+// imported from; BlackScore is black's disc count with the empty squares
+// given to the winner.
+type ReferenceGame struct {
+	ID               int64   `json:"id"`
+	Source           string  `json:"source"`
+	SourceIndex      int     `json:"source_index"`
+	Year             int     `json:"year"`
+	Tournament       *string `json:"tournament"`
+	BlackPlayer      *string `json:"black_player"`
+	WhitePlayer      *string `json:"white_player"`
+	BlackScore       int     `json:"black_score"`
+	TheoreticalScore int     `json:"theoretical_score"`
+	Transcript       string  `json:"transcript"`
+}
+
+// Game event types pushed to live subscribers.
+const (
+	EventMove        = "move"
+	EventPass        = "pass"
+	EventGuestJoined = "guest_joined"
+	EventGameOver    = "game_over"
+	EventChat        = "chat"
+	EventDrawOffer   = "draw_offered"
+	EventDrawDecline = "draw_declined"
+	// takeback_requested and takeback_declined carry the Color of the
+	// requester or decliner; takeback carries AfterMoveOrder.
+	EventTakebackRequest = "takeback_requested"
+	EventTakebackDecline = "takeback_declined"
+	EventTakeback        = "takeback"
+	// rematch_offered and rematch_declined carry the Color of the player
+	// offering or declining; rematch carries RematchPlayID.
+	EventRematchOffer   = "rematch_offered"
+	EventRematchDecline = "rematch_declined"
+	EventRematch        = "rematch"
+)
+
+// Ways a game can end, stored in games.termination.
+const (
+	TerminationNormal      = "normal"
+	TerminationResignation = "resignation"
+	TerminationAgreement   = "agreement"
+	TerminationAborted     = "aborted"
+	TerminationTimeout     = "timeout"
+	TerminationAbandoned   = "abandoned"
+)
+
+// GameEvent is a change to a game pushed to players and spectators.
+// Move is set for move and pass events, Chat for chat events, Color for draw,
+// takeback and rematch requests and replies, AfterMoveOrder for takebacks,
+// RematchPlayID for rematches and Result, Termination and the counts for
+// game_over.
+type GameEvent struct {
+	Type        string       `json:"type"`
+	PlayID      string       `json:"play_id"`
+	Move        *Move        `json:"move,omitempty"`
+	Chat        *ChatMessage `json:"chat,omitempty"`
+	Color       string       `json:"color,omitempty"`
+	Result      *string      `json:"result,omitempty"`
+	Termination *string      `json:"termination,omitempty"`
+	// AfterMoveOrder is the last move left after a takeback; clients drop the
+	// moves after it and resume from there.
+	AfterMoveOrder *int `json:"after_move_order,omitempty"`
+	// Takebacks is the game's takeback count after a takeback.
+	Takebacks     *int    `json:"takebacks,omitempty"`
+	RematchPlayID *string `json:"rematch_play_id,omitempty"`
+	BlackCount    *int    `json:"black_count,omitempty"`
+	WhiteCount    *int    `json:"white_count,omitempty"`
+}
+
+// ChatMessage is relayed to live subscribers only; it is not stored.
+type ChatMessage struct {
+	Color  string    `json:"color"`
+	Text   string    `json:"text"`
+	SentAt time.Time `json:"sent_at"`
 }
 
 type Move struct {
@@ -22,6 +134,7 @@ type Move struct {
 	Col       int       `json:"col"`
 	Row       int       `json:"row"`
 	MoveOrder int       `json:"move_order"`
+	Pass      bool      `json:"pass"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -35,10 +148,39 @@ type PlaceStoneRequest struct {
 	Secret string `json:"secret,omitempty"`
 }
 
-type EndGameRequest struct {
-	PlayID     string `json:"play_id"`
-	BlackCount int    `json:"black_count"`
-	WhiteCount int    `json:"white_count"`
+// GameActionRequest resigns, offers, accepts or declines a draw, or aborts
+// the game on behalf of the player owning Secret. Normal game completion is
+// detected by the server in PlaceStone.
+type GameActionRequest struct {
+	PlayID string `json:"play_id"`
+	Secret string `json:"secret"`
+}
+
+// EndGameRequest is the body of /end-game, which resigns.
+type EndGameRequest = GameActionRequest
+
+// StartGameRequest is optional; an empty body starts a game between two humans.
+type StartGameRequest struct {
+	Opponent string `json:"opponent,omitempty"` // "human" (default) or "ai"
+	Level    int    `json:"level,omitempty"`
+	Engine   string `json:"engine,omitempty"` // "alphabeta" (default) or "mcts"
+	// Playouts and TimeLimitMS optionally override the MCTS budget
+	Playouts    int `json:"playouts,omitempty"`
+	TimeLimitMS int `json:"time_limit_ms,omitempty"`
+	// Private games can only be watched by the players and invited spectators
+	Private bool `json:"private,omitempty"`
+	// TimeControl puts a PvP game on the clock
+	TimeControl *TimeControl `json:"time_control,omitempty"`
+	// Position is a position string to start from instead of the standard setup
+	Position string `json:"position,omitempty"`
+}
+
+// TimeControl gives each side BaseMS for the game plus IncrementMS after each
+// move, or, when MoveMS is set, MoveMS for every move.
+type TimeControl struct {
+	BaseMS      int `json:"base_ms,omitempty"`
+	IncrementMS int `json:"increment_ms,omitempty"`
+	MoveMS      int `json:"move_ms,omitempty"`
 }
 
 // Response types
@@ -48,6 +190,59 @@ type StartGameResponse struct {
 	HostSecret string `json:"host_secret,omitempty"`
 }
 
+type ChatRequest struct {
+	PlayID  string `json:"play_id"`
+	Secret  string `json:"secret"`
+	Message string `json:"message"`
+}
+
+// SpectateRequest asks for a read-only token. Private games require the
+// secret of one of the players, who can then share the token.
+type SpectateRequest struct {
+	PlayID string `json:"play_id"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// RematchResponse is pending until the opponent agrees to the rematch. Once
+// it is created, each player gets the new game with their fresh secret and
+// the color they now play.
+type RematchResponse struct {
+	Pending bool   `json:"pending"`
+	PlayID  string `json:"play_id,omitempty"`
+	Secret  string `json:"secret,omitempty"`
+	Color   string `json:"color,omitempty"`
+}
+
+// ImportGameRequest creates a game from a transcript such as "f5d6c3d3c4".
+type ImportGameRequest struct {
+	Transcript string `json:"transcript"`
+}
+
+// ImportGameResponse carries the host secret when the imported game is still
+// in progress, so that it can be played on; a guest joins as usual.
+type ImportGameResponse struct {
+	PlayID     string  `json:"play_id"`
+	HostSecret string  `json:"host_secret,omitempty"`
+	Result     *string `json:"result"`
+}
+
+// TranscriptResponse carries the start position of games that did not start
+// from the standard setup, which the transcript is played from.
+type TranscriptResponse struct {
+	PlayID        string  `json:"play_id"`
+	StartPosition *string `json:"start_position,omitempty"`
+	Transcript    string  `json:"transcript"`
+}
+
+type GGFResponse struct {
+	PlayID string `json:"play_id"`
+	GGF    string `json:"ggf"`
+}
+
+type SpectateResponse struct {
+	SpectatorToken string `json:"spectator_token"`
+}
+
 type JoinGameRequest struct {
 	PlayID string `json:"play_id"`
 }
@@ -59,10 +254,145 @@ type JoinGameResponse struct {
 type PollMovesRequest struct {
 	PlayID         string `json:"play_id"`
 	AfterMoveOrder int    `json:"after_move_order"`
+	// Takebacks is the takeback count the client's moves are from; when
+	// left out the game's current count is assumed.
+	Takebacks *int `json:"takebacks,omitempty"`
+	// Token is a host, guest or spectator token; private games require one.
+	Token string `json:"token,omitempty"`
+	// WaitMS makes the request wait up to that long for a new move
+	// instead of returning an empty list immediately.
+	WaitMS int `json:"wait_ms,omitempty"`
 }
 
 type PollMovesResponse struct {
 	Moves []Move `json:"moves"`
+	// AfterMoveOrder is set when moves the client has seen were taken back:
+	// it should drop the moves after it, and Moves continue from there.
+	AfterMoveOrder *int `json:"after_move_order,omitempty"`
+	// Takebacks is the game's takeback count, to pass on the next request.
+	Takebacks int `json:"takebacks"`
+	// Opening is the longest named book opening the game has followed.
+	Opening string `json:"opening,omitempty"`
+}
+
+// GameStateResponse is the full state of a game as returned by GET /games/{play_id}.
+type GameStateResponse struct {
+	PlayID string `json:"play_id"`
+	// Board is indexed [row][col]; cells hold "black", "white" or "" when empty.
+	Board [8][8]string `json:"board"`
+	// StartPosition is set for games that did not start from the standard setup.
+	StartPosition *string `json:"start_position,omitempty"`
+	// SideToMove is empty once the game is over.
+	SideToMove      string     `json:"side_to_move,omitempty"`
+	LegalMoves      []Position `json:"legal_moves"`
+	BlackCount      int        `json:"black_count"`
+	WhiteCount      int        `json:"white_count"`
+	MoveCount       int        `json:"move_count"`
+	Passes          []Move     `json:"passes"`
+	Result          *string    `json:"result"`
+	Termination     *string    `json:"termination"`
+	DrawOffer       *string    `json:"draw_offer"`
+	TakebackRequest *string    `json:"takeback_request"`
+	Takebacks       int        `json:"takebacks"`
+	GuestJoined     bool       `json:"guest_joined"`
+	// BlackPlayer and WhitePlayer are only known for imported games.
+	BlackPlayer *string `json:"black_player,omitempty"`
+	WhitePlayer *string `json:"white_player,omitempty"`
+	Private     bool    `json:"private"`
+	// SpectatorCount is the number of spectators watching the game's live
+	// stream right now.
+	SpectatorCount int          `json:"spectator_count"`
+	AILevel        *int         `json:"ai_level"`
+	AIEngine       *string      `json:"ai_engine"`
+	Opening        string       `json:"opening,omitempty"`
+	TimeControl    *TimeControl `json:"time_control,omitempty"`
+	// Clock is set for timed games still in progress.
+	Clock        *Clock  `json:"clock,omitempty"`
+	RematchOffer *string `json:"rematch_offer"`
+	// RematchPlayID is the rematch of this game once both players agreed.
+	RematchPlayID *string   `json:"rematch_play_id,omitempty"`
+	Series        *Series   `json:"series,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Series is the running score of a series of rematches up to and including
+// a game. Colors swap every game, so Black and White count the wins of the
+// players holding those colors in that game; aborted games are not counted.
+type Series struct {
+	ID         string `json:"id"`
+	GameNumber int    `json:"game_number"`
+	Black      int    `json:"black"`
+	White      int    `json:"white"`
+	Draws      int    `json:"draws"`
+}
+
+// Clock is the time each side has left. Running is the side whose clock is
+// ticking, empty before the first move.
+type Clock struct {
+	BlackMS int64  `json:"black_ms"`
+	WhiteMS int64  `json:"white_ms"`
+	Running string `json:"running,omitempty"`
+}
+
+type AnalyzeEndgameRequest struct {
+	PlayID string `json:"play_id"`
+	Token  string `json:"token,omitempty"`
+	// MoveNumber selects the position after that many recorded moves;
+	// nil analyses the latest position.
+	MoveNumber *int `json:"move_number,omitempty"`
+}
+
+type LineMove struct {
+	Color string `json:"color"`
+	Col   int    `json:"col"`
+	Row   int    `json:"row"`
+	Pass  bool   `json:"pass"`
+}
+
+type AnalyzeEndgameResponse struct {
+	PlayID     string `json:"play_id"`
+	MoveNumber int    `json:"move_number"`
+	SideToMove string `json:"side_to_move,omitempty"`
+	Empties    int    `json:"empties"`
+	// DiscDiff is the final black minus white disc count under perfect play.
+	DiscDiff int        `json:"disc_diff"`
+	BestLine []LineMove `json:"best_line"`
+}
+
+type HintRequest struct {
+	PlayID string `json:"play_id"`
+	Token  string `json:"token,omitempty"`
+	// MoveNumber selects the position after that many recorded moves;
+	// nil uses the latest position.
+	MoveNumber *int `json:"move_number,omitempty"`
+	// TimeLimitMS bounds the search; zero uses the server default.
+	TimeLimitMS int `json:"time_limit_ms,omitempty"`
+}
+
+type Position struct {
+	Col int `json:"col"`
+	Row int `json:"row"`
+}
+
+type HintMove struct {
+	Col int `json:"col"`
+	Row int `json:"row"`
+	// Score is from the side to move's point of view: the final disc
+	// differential when the response is exact, an engine score otherwise.
+	Score int        `json:"score"`
+	Flips []Position `json:"flips"`
+	Line  []LineMove `json:"line"`
+}
+
+type HintResponse struct {
+	PlayID     string `json:"play_id"`
+	MoveNumber int    `json:"move_number"`
+	SideToMove string `json:"side_to_move,omitempty"`
+	Exact      bool   `json:"exact"`
+	Depth      int    `json:"depth"`
+	// Moves lists every legal move, best first.
+	Moves []HintMove `json:"moves"`
 }
 
 type SuccessResponse struct {
@@ -168,7 +168,7 @@ func TestPollMovesResponseJSON(t *testing.T) {
 }
 
 func TestEndGameRequestJSON(t *testing.T) {
-	jsonStr := `{"play_id":"xyz","black_count":40,"white_count":24}`
+	jsonStr := `{"play_id":"xyz","secret":"my-secret"}`
 	var req EndGameRequest
 	if err := json.Unmarshal([]byte(jsonStr), &req); err != nil {
 		t.Fatalf("failed to unmarshal: %v", err)
@@ -176,7 +176,7 @@ func TestEndGameRequestJSON(t *testing.T) {
 	if req.PlayID != "xyz" {
 		t.Fatalf("expected play_id xyz, got %s", req.PlayID)
 	}
-	if req.BlackCount != 40 || req.WhiteCount != 24 {
-		t.Fatalf("expected 40/24, got %d/%d", req.BlackCount, req.WhiteCount)
+	if req.Secret != "my-secret" {
+		t.Fatalf("expected secret my-secret, got %s", req.Secret)
 	}
 }
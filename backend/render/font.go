@@ -0,0 +1,47 @@
+package render
+
+import "image"
+
+// The coordinates are drawn from 5x7 glyphs, each dot glyphScale pixels wide.
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	glyphScale  = 2
+)
+
+var glyphs = map[byte][glyphHeight]string{
+	'a': {".....", ".....", ".###.", "....#", ".####", "#...#", ".####"},
+	'b': {"#....", "#....", "####.", "#...#", "#...#", "#...#", "####."},
+	'c': {".....", ".....", ".###.", "#....", "#....", "#...#", ".###."},
+	'd': {"....#", "....#", ".####", "#...#", "#...#", "#...#", ".####"},
+	'e': {".....", ".....", ".###.", "#...#", "#####", "#....", ".###."},
+	'f': {"..##.", ".#..#", ".#...", "###..", ".#...", ".#...", ".#..."},
+	'g': {".....", ".####", "#...#", "#...#", ".####", "....#", ".###."},
+	'h': {"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "#...#"},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+}
+
+// drawGlyph draws ch centered on c in the label color.
+func drawGlyph(img *image.Paletted, c image.Point, ch byte) {
+	g, ok := glyphs[ch]
+	if !ok {
+		return
+	}
+	origin := c.Sub(image.Pt(glyphWidth*glyphScale/2, glyphHeight*glyphScale/2))
+	for y, line := range g {
+		for x := 0; x < glyphWidth; x++ {
+			if line[x] != '#' {
+				continue
+			}
+			p := origin.Add(image.Pt(x*glyphScale, y*glyphScale))
+			fillRect(img, image.Rect(p.X, p.Y, p.X+glyphScale, p.Y+glyphScale), labelColor)
+		}
+	}
+}
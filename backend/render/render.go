@@ -0,0 +1,212 @@
+// Package render draws boards as SVG documents or raster images using only
+// the standard library; the raster coordinates use a small built-in font.
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// Layout in pixels: the board is framed by a margin holding the coordinates.
+const (
+	CellSize = 48
+	Margin   = 24
+	Size     = 2*Margin + board.Size*CellSize
+
+	discRadius   = CellSize/2 - 4
+	markerRadius = 5
+	dotRadius    = 6
+)
+
+// Scene is what gets drawn: a position, the move that led to it and
+// optionally the legal moves of the side to move.
+type Scene struct {
+	Board board.Board
+	Turn  board.Color
+	// LastMove is marked on its disc; nil at the start of the game.
+	LastMove *board.Position
+	// LegalMoves dots the squares Turn may play.
+	LegalMoves bool
+}
+
+// Palette holds every color drawn, so raster images can be paletted; its
+// first entry is the frame color.
+var Palette = color.Palette{
+	frameColor,
+	boardColor,
+	gridColor,
+	blackColor,
+	whiteColor,
+	outlineColor,
+	markerColor,
+	labelColor,
+}
+
+var (
+	frameColor   = color.RGBA{0x2d, 0x2d, 0x2d, 0xff}
+	boardColor   = color.RGBA{0x2e, 0x7d, 0x32, 0xff}
+	gridColor    = color.RGBA{0x1b, 0x5e, 0x20, 0xff}
+	blackColor   = color.RGBA{0x11, 0x11, 0x11, 0xff}
+	whiteColor   = color.RGBA{0xf5, 0xf5, 0xf5, 0xff}
+	outlineColor = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	markerColor  = color.RGBA{0xe5, 0x39, 0x35, 0xff}
+	labelColor   = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+)
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// center returns the pixel center of a square.
+func center(col, row int) (int, int) {
+	return Margin + col*CellSize + CellSize/2, Margin + row*CellSize + CellSize/2
+}
+
+func legalMoves(s Scene) []board.Position {
+	if !s.LegalMoves || s.Turn == board.Empty {
+		return nil
+	}
+	return s.Board.ValidMoves(s.Turn)
+}
+
+// SVG writes the scene as an SVG document.
+func SVG(w io.Writer, s Scene) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, Size, Size, Size, Size)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="%s"/>`, Size, Size, hex(frameColor))
+	fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, Margin, Margin, board.Size*CellSize, board.Size*CellSize, hex(boardColor))
+	for i := 0; i <= board.Size; i++ {
+		p := Margin + i*CellSize
+		fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="2"/>`, p, Margin, p, Size-Margin, hex(gridColor))
+		fmt.Fprintf(&sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="2"/>`, Margin, p, Size-Margin, p, hex(gridColor))
+	}
+
+	sb.WriteString(`<g font-family="sans-serif" font-size="14" text-anchor="middle" dominant-baseline="central" fill="` + hex(labelColor) + `">`)
+	for i := 0; i < board.Size; i++ {
+		x, y := center(i, i)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%c</text>`, x, Margin/2, 'a'+i)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%c</text>`, x, Size-Margin/2, 'a'+i)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%d</text>`, Margin/2, y, i+1)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%d</text>`, Size-Margin/2, y, i+1)
+	}
+	sb.WriteString(`</g>`)
+
+	for row := 0; row < board.Size; row++ {
+		for col := 0; col < board.Size; col++ {
+			x, y := center(col, row)
+			switch s.Board.At(col, row) {
+			case board.Black:
+				fmt.Fprintf(&sb, `<circle cx="%d" cy="%d" r="%d" fill="%s"/>`, x, y, discRadius, hex(blackColor))
+			case board.White:
+				fmt.Fprintf(&sb, `<circle cx="%d" cy="%d" r="%d" fill="%s" stroke="%s" stroke-width="1"/>`, x, y, discRadius, hex(whiteColor), hex(outlineColor))
+			}
+		}
+	}
+	for _, p := range legalMoves(s) {
+		x, y := center(p.Col, p.Row)
+		fmt.Fprintf(&sb, `<circle class="legal" cx="%d" cy="%d" r="%d" fill="%s"/>`, x, y, dotRadius, hex(gridColor))
+	}
+	if p := s.LastMove; p != nil {
+		x, y := center(p.Col, p.Row)
+		fmt.Fprintf(&sb, `<circle class="last-move" cx="%d" cy="%d" r="%d" fill="%s"/>`, x, y, markerRadius, hex(markerColor))
+	}
+	sb.WriteString(`</svg>`)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Image draws the scene as a Size x Size image using Palette.
+func Image(s Scene) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, Size, Size), Palette)
+	Draw(img, image.Point{}, s)
+	return img
+}
+
+// Draw draws the scene onto img with its top left corner at at. img must use
+// a palette holding Palette.
+func Draw(img *image.Paletted, at image.Point, s Scene) {
+	fillRect(img, image.Rect(0, 0, Size, Size).Add(at), frameColor)
+	fillRect(img, image.Rect(Margin, Margin, Size-Margin, Size-Margin).Add(at), boardColor)
+	for i := 0; i <= board.Size; i++ {
+		p := Margin + i*CellSize
+		fillRect(img, image.Rect(p-1, Margin-1, p+1, Size-Margin+1).Add(at), gridColor)
+		fillRect(img, image.Rect(Margin-1, p-1, Size-Margin+1, p+1).Add(at), gridColor)
+	}
+
+	for i := 0; i < board.Size; i++ {
+		x, y := center(i, i)
+		col, row := byte('a'+i), byte('1'+i)
+		drawGlyph(img, at.Add(image.Pt(x, Margin/2)), col)
+		drawGlyph(img, at.Add(image.Pt(x, Size-Margin/2)), col)
+		drawGlyph(img, at.Add(image.Pt(Margin/2, y)), row)
+		drawGlyph(img, at.Add(image.Pt(Size-Margin/2, y)), row)
+	}
+
+	for row := 0; row < board.Size; row++ {
+		for col := 0; col < board.Size; col++ {
+			c := at.Add(image.Pt(center(col, row)))
+			switch s.Board.At(col, row) {
+			case board.Black:
+				fillCircle(img, c, discRadius, blackColor)
+			case board.White:
+				fillCircle(img, c, discRadius, outlineColor)
+				fillCircle(img, c, discRadius-1, whiteColor)
+			}
+		}
+	}
+	for _, p := range legalMoves(s) {
+		fillCircle(img, at.Add(image.Pt(center(p.Col, p.Row))), dotRadius, gridColor)
+	}
+	if p := s.LastMove; p != nil {
+		fillCircle(img, at.Add(image.Pt(center(p.Col, p.Row))), markerRadius, markerColor)
+	}
+}
+
+// EvalBarHeight is the height of the bar drawn by DrawEvalBar.
+const EvalBarHeight = 20
+
+// DrawEvalBar draws an evaluation bar Size pixels wide below a board drawn at
+// the same x: black fills the share of the bar given by black, from 0 to 1,
+// from the left and white the rest; a mark shows the even point.
+func DrawEvalBar(img *image.Paletted, at image.Point, black float64) {
+	black = math.Max(0, math.Min(1, black))
+	fillRect(img, image.Rect(0, 0, Size, EvalBarHeight).Add(at), frameColor)
+
+	const top, bottom = 2, EvalBarHeight - 6
+	split := Margin + int(math.Round(black*board.Size*CellSize))
+	fillRect(img, image.Rect(Margin, top, split, bottom).Add(at), blackColor)
+	fillRect(img, image.Rect(split, top, Size-Margin, bottom).Add(at), whiteColor)
+	mid := Size / 2
+	fillRect(img, image.Rect(mid-1, top-2, mid+1, bottom+2).Add(at), markerColor)
+}
+
+func fillRect(img *image.Paletted, r image.Rectangle, c color.Color) {
+	i := uint8(img.Palette.Index(c))
+	r = r.Intersect(img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetColorIndex(x, y, i)
+		}
+	}
+}
+
+func fillCircle(img *image.Paletted, c image.Point, radius int, col color.Color) {
+	i := uint8(img.Palette.Index(col))
+	// sampling pixel centers keeps the circle symmetric around c
+	r2 := radius * radius
+	for dy := -radius; dy < radius; dy++ {
+		for dx := -radius; dx < radius; dx++ {
+			px, py := 2*dx+1, 2*dy+1
+			if px*px+py*py <= 4*r2 {
+				img.SetColorIndex(c.X+dx, c.Y+dy, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package render
+
+import (
+	"encoding/xml"
+	"image"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// startScene is the position after f5, with white's moves dotted.
+func startScene(t *testing.T) Scene {
+	t.Helper()
+	b := board.New()
+	if err := b.Place(board.Black, 5, 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return Scene{Board: *b, Turn: board.White, LastMove: &board.Position{Col: 5, Row: 4}, LegalMoves: true}
+}
+
+func TestSVG(t *testing.T) {
+	var sb strings.Builder
+	if err := SVG(&sb, startScene(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := sb.String()
+
+	// the document is well formed
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		if _, err := d.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("malformed SVG: %v", err)
+		}
+	}
+
+	if n := strings.Count(s, `fill="#111111"`); n != 4 {
+		t.Fatalf("expected 4 black discs, got %d", n)
+	}
+	if n := strings.Count(s, `fill="#f5f5f5"`); n != 1 {
+		t.Fatalf("expected 1 white disc, got %d", n)
+	}
+	if n := strings.Count(s, `class="legal"`); n != 3 {
+		t.Fatalf("expected white's 3 legal moves, got %d", n)
+	}
+	if !strings.Contains(s, `class="last-move" cx="288" cy="240"`) {
+		t.Fatalf("expected the last move marker on f5, got %s", s)
+	}
+	if !strings.Contains(s, `>a</text>`) || !strings.Contains(s, `>8</text>`) {
+		t.Fatal("expected the coordinates")
+	}
+}
+
+func TestSVG_NoLegalMoves(t *testing.T) {
+	s := startScene(t)
+	s.LegalMoves = false
+	s.LastMove = nil
+
+	var sb strings.Builder
+	SVG(&sb, s)
+	if strings.Contains(sb.String(), `class="legal"`) || strings.Contains(sb.String(), `class="last-move"`) {
+		t.Fatal("expected neither legal move dots nor a last move marker")
+	}
+}
+
+func TestImage(t *testing.T) {
+	img := Image(startScene(t))
+
+	if img.Bounds() != image.Rect(0, 0, Size, Size) {
+		t.Fatalf("expected a %dx%d image, got %v", Size, Size, img.Bounds())
+	}
+	tests := []struct {
+		name     string
+		col, row int
+		// dx shifts the sample off the center, away from the marker
+		dx   int
+		want any
+	}{
+		{"white disc on d4", 3, 3, 0, whiteColor},
+		{"black disc on e4", 4, 3, 0, blackColor},
+		{"marker on f5", 5, 4, 0, markerColor},
+		{"black disc on f5", 5, 4, markerRadius + 2, blackColor},
+		{"legal dot on f6", 5, 5, 0, gridColor},
+		{"empty a1", 0, 0, 0, boardColor},
+	}
+	for _, tt := range tests {
+		x, y := center(tt.col, tt.row)
+		if got := img.At(x+tt.dx, y); got != tt.want {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+	if got := img.At(0, 0); got != frameColor {
+		t.Fatalf("expected the frame in the corner, got %v", got)
+	}
+
+	// the column labels leave label pixels in the top margin
+	labels := 0
+	for y := 0; y < Margin; y++ {
+		for x := Margin; x < Size-Margin; x++ {
+			if img.At(x, y) == labelColor {
+				labels++
+			}
+		}
+	}
+	if labels == 0 {
+		t.Fatal("expected the coordinates in the margin")
+	}
+}
+
+func TestDrawEvalBar(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, Size, EvalBarHeight), Palette)
+	DrawEvalBar(img, image.Point{}, 0.25)
+
+	y := EvalBarHeight / 2
+	quarter := Margin + board.Size*CellSize/4
+	if got := img.At(quarter-2, y); got != blackColor {
+		t.Fatalf("expected black left of the quarter, got %v", got)
+	}
+	if got := img.At(quarter+2, y); got != whiteColor {
+		t.Fatalf("expected white right of the quarter, got %v", got)
+	}
+	if got := img.At(Size/2, y); got != markerColor {
+		t.Fatalf("expected the even mark in the middle, got %v", got)
+	}
+
+	// out of range shares are clamped
+	DrawEvalBar(img, image.Point{}, 2)
+	if got := img.At(Size-Margin-1, y); got != blackColor {
+		t.Fatalf("expected a full black bar, got %v", got)
+	}
+}
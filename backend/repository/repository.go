@@ -3,21 +3,47 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/dog-nose/othello-backend/model"
 )
 
-var ErrGuestAlreadyJoined = errors.New("guest already joined or game not found")
+var (
+	ErrGuestAlreadyJoined = errors.New("guest already joined or game not found")
+	ErrGameAlreadyEnded   = errors.New("game already ended or not found")
+	ErrGameNotFound       = errors.New("game not found")
+	ErrNoRematchOffer     = errors.New("no rematch offered or game not found")
+)
 
 type Repository interface {
 	CreateGame(playID string) error
 	CreateGameWithSecret(playID, hostSecret string) error
+	CreateAIGame(playID, hostSecret, engine string, level, playouts, timeLimitMS int) error
 	GetGame(playID string) (*model.Game, error)
 	RecordMove(playID, color string, col, row, moveOrder int) error
+	RecordPass(playID, color string, moveOrder int) error
 	GetMoveCount(playID string) (int, error)
-	EndGame(playID string, blackCount, whiteCount int, result string) error
+	EndGame(playID string, blackCount, whiteCount int, result, termination string) error
+	SetDrawOffer(playID string, color *string) error
+	SetTakebackRequest(playID string, color *string) error
+	TakeBack(playID string, fromMoveOrder int, requestedBy string) error
+	GetFirstRevertedMoveOrder(playID string, upTo, sinceTakebacks int) (int, bool, error)
 	SetGuestSecret(playID, guestSecret string) error
 	GetMovesAfter(playID string, afterMoveOrder int) ([]model.Move, error)
+	SetPrivate(playID string) error
+	SetTimeControl(playID string, baseMS, incrementMS, moveMS int) error
+	SetStartPosition(playID, position string) error
+	GetTimedGames() ([]model.Game, error)
+	GetInactiveGames(idle time.Duration) ([]model.Game, error)
+	PurgeAbandonedGames(age time.Duration, archive bool) (int64, error)
+	AddSpectator(playID, token string) error
+	IsSpectator(playID, token string) (bool, error)
+	SetRematchOffer(playID string, color *string) error
+	CreateRematch(playID, previousPlayID, hostSecret, guestSecret string) error
+	GetRematch(playID string) (*model.Game, error)
+	GetSeries(seriesID string) ([]model.Game, error)
+	ImportGame(game *model.Game, moves []model.Move) error
+	ImportReferenceGames(games []model.ReferenceGame) (int64, error)
 }
 
 type MySQLRepository struct {
@@ -38,21 +64,112 @@ func (r *MySQLRepository) CreateGameWithSecret(playID, hostSecret string) error
 	return err
 }
 
-func (r *MySQLRepository) GetGame(playID string) (*model.Game, error) {
+// CreateAIGame stores a game against the server. Zero playouts or timeLimitMS
+// are stored as NULL so the engine's level defaults apply.
+func (r *MySQLRepository) CreateAIGame(playID, hostSecret, engine string, level, playouts, timeLimitMS int) error {
+	_, err := r.db.Exec(
+		"INSERT INTO games (play_id, host_secret, ai_level, ai_engine, ai_playouts, ai_time_limit_ms) VALUES (?, ?, ?, ?, NULLIF(?, 0), NULLIF(?, 0))",
+		playID, hostSecret, level, engine, playouts, timeLimitMS,
+	)
+	return err
+}
+
+const gameColumns = "play_id, black_count, white_count, result, termination, draw_offer, takeback_request, takebacks, host_secret, guest_secret, ai_level, ai_engine, ai_playouts, ai_time_limit_ms, private, clock_base_ms, clock_increment_ms, clock_move_ms, clock_resumed_at, rematch_of, series_id, rematch_offer, black_player, white_player, black_rating, white_rating, played_at, start_position, created_at, updated_at"
+
+func scanGame(row interface{ Scan(...any) error }) (*model.Game, error) {
 	game := &model.Game{}
-	err := r.db.QueryRow(
-		"SELECT play_id, black_count, white_count, result, host_secret, guest_secret, created_at, updated_at FROM games WHERE play_id = ?",
-		playID,
-	).Scan(&game.PlayID, &game.BlackCount, &game.WhiteCount, &game.Result, &game.HostSecret, &game.GuestSecret, &game.CreatedAt, &game.UpdatedAt)
+	err := row.Scan(&game.PlayID, &game.BlackCount, &game.WhiteCount, &game.Result, &game.Termination, &game.DrawOffer, &game.TakebackRequest, &game.Takebacks, &game.HostSecret, &game.GuestSecret, &game.AILevel, &game.AIEngine, &game.AIPlayouts, &game.AITimeLimitMS, &game.Private, &game.ClockBaseMS, &game.ClockIncrementMS, &game.ClockMoveMS, &game.ClockResumedAt, &game.RematchOf, &game.SeriesID, &game.RematchOffer, &game.BlackPlayer, &game.WhitePlayer, &game.BlackRating, &game.WhiteRating, &game.PlayedAt, &game.StartPosition, &game.CreatedAt, &game.UpdatedAt)
+	return game, err
+}
+
+func (r *MySQLRepository) GetGame(playID string) (*model.Game, error) {
+	game, err := scanGame(r.db.QueryRow("SELECT "+gameColumns+" FROM games WHERE play_id = ?", playID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGameNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return game, nil
 }
 
+// GetTimedGames returns the games in progress that are played on the clock.
+func (r *MySQLRepository) GetTimedGames() ([]model.Game, error) {
+	return r.queryGames("SELECT " + gameColumns + " FROM games WHERE result IS NULL AND (clock_base_ms IS NOT NULL OR clock_move_ms IS NOT NULL)")
+}
+
+// GetInactiveGames returns the games in progress that have neither been
+// updated nor had a move recorded for idle.
+func (r *MySQLRepository) GetInactiveGames(idle time.Duration) ([]model.Game, error) {
+	seconds := int64(idle.Seconds())
+	return r.queryGames(
+		"SELECT "+gameColumns+" FROM games WHERE result IS NULL AND updated_at < NOW() - INTERVAL ? SECOND AND NOT EXISTS (SELECT 1 FROM moves WHERE moves.play_id = games.play_id AND moves.created_at >= NOW(3) - INTERVAL ? SECOND)",
+		seconds, seconds,
+	)
+}
+
+// PurgeAbandonedGames deletes the games abandoned more than age ago with their
+// moves, spectators and takebacks. With archive the games and their moves are
+// first copied to archived_games and archived_moves. It returns the number of
+// games removed.
+func (r *MySQLRepository) PurgeAbandonedGames(age time.Duration, archive bool) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Every statement is bounded by the same instant
+	var before time.Time
+	if err := tx.QueryRow("SELECT NOW() - INTERVAL ? SECOND", int64(age.Seconds())).Scan(&before); err != nil {
+		return 0, err
+	}
+	const abandoned = "SELECT play_id FROM games WHERE termination = 'abandoned' AND updated_at < ?"
+	if archive {
+		if _, err := tx.Exec("INSERT INTO archived_games SELECT * FROM games WHERE play_id IN ("+abandoned+")", before); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("INSERT INTO archived_moves SELECT * FROM moves WHERE play_id IN ("+abandoned+")", before); err != nil {
+			return 0, err
+		}
+	}
+	for _, table := range []string{"spectators", "reverted_moves", "moves"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE play_id IN ("+abandoned+")", before); err != nil {
+			return 0, err
+		}
+	}
+	res, err := tx.Exec("DELETE FROM games WHERE termination = 'abandoned' AND updated_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+func (r *MySQLRepository) queryGames(query string, args ...any) ([]model.Game, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []model.Game
+	for rows.Next() {
+		game, err := scanGame(rows)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, *game)
+	}
+	return games, rows.Err()
+}
+
 func (r *MySQLRepository) SetGuestSecret(playID, guestSecret string) error {
 	result, err := r.db.Exec(
-		"UPDATE games SET guest_secret = ? WHERE play_id = ? AND guest_secret IS NULL",
+		"UPDATE games SET guest_secret = ?, clock_resumed_at = CURRENT_TIMESTAMP(3) WHERE play_id = ? AND guest_secret IS NULL AND ai_level IS NULL",
 		guestSecret, playID,
 	)
 	if err != nil {
@@ -68,9 +185,147 @@ func (r *MySQLRepository) SetGuestSecret(playID, guestSecret string) error {
 	return nil
 }
 
+// SetPrivate restricts the game to its players and invited spectators.
+func (r *MySQLRepository) SetPrivate(playID string) error {
+	_, err := r.db.Exec("UPDATE games SET private = TRUE WHERE play_id = ?", playID)
+	return err
+}
+
+// SetStartPosition makes the game start from position instead of the
+// standard setup.
+func (r *MySQLRepository) SetStartPosition(playID, position string) error {
+	_, err := r.db.Exec("UPDATE games SET start_position = ? WHERE play_id = ?", position, playID)
+	return err
+}
+
+// SetTimeControl puts the game on the clock. Zero values are stored as NULL.
+func (r *MySQLRepository) SetTimeControl(playID string, baseMS, incrementMS, moveMS int) error {
+	_, err := r.db.Exec(
+		"UPDATE games SET clock_base_ms = NULLIF(?, 0), clock_increment_ms = NULLIF(?, 0), clock_move_ms = NULLIF(?, 0) WHERE play_id = ?",
+		baseMS, incrementMS, moveMS, playID,
+	)
+	return err
+}
+
+func (r *MySQLRepository) AddSpectator(playID, token string) error {
+	_, err := r.db.Exec("INSERT INTO spectators (play_id, token) VALUES (?, ?)", playID, token)
+	return err
+}
+
+func (r *MySQLRepository) IsSpectator(playID, token string) (bool, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM spectators WHERE play_id = ? AND token = ?", playID, token).Scan(&count)
+	return count > 0, err
+}
+
+// SetDrawOffer records the color of the player offering a draw, or clears the
+// offer when color is nil.
+func (r *MySQLRepository) SetDrawOffer(playID string, color *string) error {
+	_, err := r.db.Exec("UPDATE games SET draw_offer = ? WHERE play_id = ? AND result IS NULL", color, playID)
+	return err
+}
+
+// SetTakebackRequest records the color of the player asking to undo their
+// last move, or clears the request when color is nil.
+func (r *MySQLRepository) SetTakebackRequest(playID string, color *string) error {
+	_, err := r.db.Exec("UPDATE games SET takeback_request = ? WHERE play_id = ? AND result IS NULL", color, playID)
+	return err
+}
+
+// TakeBack removes the moves from fromMoveOrder on, clears the takeback
+// request, counts the takeback and restarts the clocks. The removed rows are
+// kept in reverted_moves with the count.
+func (r *MySQLRepository) TakeBack(playID string, fromMoveOrder int, requestedBy string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE games SET takeback_request = NULL, takebacks = takebacks + 1, clock_resumed_at = CURRENT_TIMESTAMP(3) WHERE play_id = ?", playID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO reverted_moves (play_id, color, col, `row`, move_order, pass, created_at, requested_by, takeback) SELECT play_id, color, col, `row`, move_order, pass, created_at, ?, (SELECT takebacks FROM games WHERE play_id = ?) FROM moves WHERE play_id = ? AND move_order >= ?",
+		requestedBy, playID, playID, fromMoveOrder,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM moves WHERE play_id = ? AND move_order >= ?", playID, fromMoveOrder); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetRematchOffer records the color of the player offering a rematch of a
+// finished game, or clears the offer when color is nil.
+func (r *MySQLRepository) SetRematchOffer(playID string, color *string) error {
+	_, err := r.db.Exec("UPDATE games SET rematch_offer = ? WHERE play_id = ? AND result IS NOT NULL", color, playID)
+	return err
+}
+
+// CreateRematch accepts the pending rematch offer of the previous game and
+// creates the new game with the previous settings. The previous game starts
+// the series if it was not part of one yet.
+func (r *MySQLRepository) CreateRematch(playID, previousPlayID, hostSecret, guestSecret string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE games SET rematch_offer = NULL, series_id = COALESCE(series_id, play_id) WHERE play_id = ? AND rematch_offer IS NOT NULL",
+		previousPlayID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNoRematchOffer
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO games (play_id, host_secret, guest_secret, private, clock_base_ms, clock_increment_ms, clock_move_ms, clock_resumed_at, start_position, rematch_of, series_id) SELECT ?, ?, ?, private, clock_base_ms, clock_increment_ms, clock_move_ms, CURRENT_TIMESTAMP(3), start_position, play_id, series_id FROM games WHERE play_id = ?",
+		playID, hostSecret, guestSecret, previousPlayID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetRematch returns the game created as a rematch of playID.
+func (r *MySQLRepository) GetRematch(playID string) (*model.Game, error) {
+	game, err := scanGame(r.db.QueryRow("SELECT "+gameColumns+" FROM games WHERE rematch_of = ?", playID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// GetSeries returns the games of a series in no particular order; they are
+// chained by RematchOf.
+func (r *MySQLRepository) GetSeries(seriesID string) ([]model.Game, error) {
+	return r.queryGames("SELECT "+gameColumns+" FROM games WHERE series_id = ?", seriesID)
+}
+
+// GetFirstRevertedMoveOrder returns the lowest move_order up to upTo taken
+// back in the game by a takeback after the first sinceTakebacks.
+func (r *MySQLRepository) GetFirstRevertedMoveOrder(playID string, upTo, sinceTakebacks int) (int, bool, error) {
+	var first sql.NullInt64
+	err := r.db.QueryRow("SELECT MIN(move_order) FROM reverted_moves WHERE play_id = ? AND move_order <= ? AND takeback > ?", playID, upTo, sinceTakebacks).Scan(&first)
+	return int(first.Int64), first.Valid, err
+}
+
 func (r *MySQLRepository) GetMovesAfter(playID string, afterMoveOrder int) ([]model.Move, error) {
 	rows, err := r.db.Query(
-		"SELECT id, play_id, color, col, `row`, move_order, created_at FROM moves WHERE play_id = ? AND move_order > ? ORDER BY move_order ASC",
+		"SELECT id, play_id, color, col, `row`, move_order, pass, created_at FROM moves WHERE play_id = ? AND move_order > ? ORDER BY move_order ASC",
 		playID, afterMoveOrder,
 	)
 	if err != nil {
@@ -81,7 +336,7 @@ func (r *MySQLRepository) GetMovesAfter(playID string, afterMoveOrder int) ([]mo
 	var moves []model.Move
 	for rows.Next() {
 		var m model.Move
-		if err := rows.Scan(&m.ID, &m.PlayID, &m.Color, &m.Col, &m.Row, &m.MoveOrder, &m.CreatedAt); err != nil {
+		if err := rows.Scan(&m.ID, &m.PlayID, &m.Color, &m.Col, &m.Row, &m.MoveOrder, &m.Pass, &m.CreatedAt); err != nil {
 			return nil, err
 		}
 		moves = append(moves, m)
@@ -92,6 +347,66 @@ func (r *MySQLRepository) GetMovesAfter(playID string, afterMoveOrder int) ([]mo
 	return moves, rows.Err()
 }
 
+// ImportGame stores a game played elsewhere with its secrets, players, time
+// control, its outcome if it is finished and all of its moves.
+func (r *MySQLRepository) ImportGame(game *model.Game, moves []model.Move) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"INSERT INTO games (play_id, host_secret, guest_secret, black_count, white_count, result, termination, clock_base_ms, clock_increment_ms, black_player, white_player, black_rating, white_rating, played_at, start_position) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		game.PlayID, game.HostSecret, game.GuestSecret, game.BlackCount, game.WhiteCount, game.Result, game.Termination,
+		game.ClockBaseMS, game.ClockIncrementMS, game.BlackPlayer, game.WhitePlayer, game.BlackRating, game.WhiteRating, game.PlayedAt, game.StartPosition,
+	); err != nil {
+		return err
+	}
+	for _, m := range moves {
+		if _, err := tx.Exec(
+			"INSERT INTO moves (play_id, color, col, `row`, move_order, pass) VALUES (?, ?, ?, ?, ?, ?)",
+			game.PlayID, m.Color, m.Col, m.Row, m.MoveOrder, m.Pass,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ImportReferenceGames stores games in the reference archive in a single
+// transaction. Games already imported from the same place in the same source
+// file are left as they are; it returns the number of games added.
+func (r *MySQLRepository) ImportReferenceGames(games []model.ReferenceGame) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		"INSERT INTO reference_games (source, source_index, year, tournament, black_player, white_player, black_score, theoretical_score, transcript) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE id = id",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var added int64
+	for _, g := range games {
+		res, err := stmt.Exec(g.Source, g.SourceIndex, g.Year, g.Tournament, g.BlackPlayer, g.WhitePlayer, g.BlackScore, g.TheoreticalScore, g.Transcript)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		added += n
+	}
+	return added, tx.Commit()
+}
+
 func (r *MySQLRepository) RecordMove(playID, color string, col, row, moveOrder int) error {
 	_, err := r.db.Exec(
 		"INSERT INTO moves (play_id, color, col, `row`, move_order) VALUES (?, ?, ?, ?, ?)",
@@ -100,16 +415,37 @@ func (r *MySQLRepository) RecordMove(playID, color string, col, row, moveOrder i
 	return err
 }
 
+// RecordPass stores a pass as a move row without a square (col and row are -1).
+func (r *MySQLRepository) RecordPass(playID, color string, moveOrder int) error {
+	_, err := r.db.Exec(
+		"INSERT INTO moves (play_id, color, col, `row`, move_order, pass) VALUES (?, ?, -1, -1, ?, TRUE)",
+		playID, color, moveOrder,
+	)
+	return err
+}
+
 func (r *MySQLRepository) GetMoveCount(playID string) (int, error) {
 	var count int
 	err := r.db.QueryRow("SELECT COUNT(*) FROM moves WHERE play_id = ?", playID).Scan(&count)
 	return count, err
 }
 
-func (r *MySQLRepository) EndGame(playID string, blackCount, whiteCount int, result string) error {
-	_, err := r.db.Exec(
-		"UPDATE games SET black_count = ?, white_count = ?, result = ? WHERE play_id = ?",
-		blackCount, whiteCount, result, playID,
+// EndGame records the outcome and how the game ended, withdrawing any
+// pending draw offer.
+func (r *MySQLRepository) EndGame(playID string, blackCount, whiteCount int, result, termination string) error {
+	res, err := r.db.Exec(
+		"UPDATE games SET black_count = ?, white_count = ?, result = ?, termination = ?, draw_offer = NULL WHERE play_id = ? AND result IS NULL",
+		blackCount, whiteCount, result, termination, playID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrGameAlreadyEnded
+	}
+	return nil
 }
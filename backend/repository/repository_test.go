@@ -3,7 +3,9 @@ package repository
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/dog-nose/othello-backend/model"
 	"github.com/dog-nose/othello-backend/testutil"
 )
 
@@ -82,7 +84,7 @@ func TestEndGame(t *testing.T) {
 		t.Fatalf("failed to create game: %v", err)
 	}
 
-	err = repo.EndGame("test-play-id-3", 40, 24, "black_win")
+	err = repo.EndGame("test-play-id-3", 40, 24, "black_win", "normal")
 	if err != nil {
 		t.Fatalf("failed to end game: %v", err)
 	}
@@ -100,6 +102,14 @@ func TestEndGame(t *testing.T) {
 	if game.WhiteCount == nil || *game.WhiteCount != 24 {
 		t.Fatalf("expected white_count 24, got %v", game.WhiteCount)
 	}
+	if game.Termination == nil || *game.Termination != "normal" {
+		t.Fatalf("expected termination normal, got %v", game.Termination)
+	}
+
+	err = repo.EndGame("test-play-id-3", 10, 54, "white_win", "resignation")
+	if !errors.Is(err, ErrGameAlreadyEnded) {
+		t.Fatalf("expected ErrGameAlreadyEnded, got %v", err)
+	}
 }
 
 func TestCreateGameWithSecret(t *testing.T) {
@@ -153,6 +163,9 @@ func TestSetGuestSecret(t *testing.T) {
 	if game.GuestSecret == nil || *game.GuestSecret != "guest-secret-456" {
 		t.Fatalf("expected guest_secret guest-secret-456, got %v", game.GuestSecret)
 	}
+	if game.ClockResumedAt == nil {
+		t.Fatal("expected the clocks to start when the guest joins")
+	}
 }
 
 func TestSetGuestSecret_AlreadySet(t *testing.T) {
@@ -229,3 +242,498 @@ func TestGetMovesAfter_NoMoves(t *testing.T) {
 		t.Fatalf("expected 0 moves, got %d", len(moves))
 	}
 }
+
+func TestRecordPass(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	err := repo.CreateGame("test-pass-1")
+	if err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+
+	repo.RecordMove("test-pass-1", "black", 2, 3, 1)
+	if err := repo.RecordPass("test-pass-1", "white", 2); err != nil {
+		t.Fatalf("failed to record pass: %v", err)
+	}
+
+	moves, err := repo.GetMovesAfter("test-pass-1", 0)
+	if err != nil {
+		t.Fatalf("failed to get moves after: %v", err)
+	}
+	if len(moves) != 2 {
+		t.Fatalf("expected 2 moves, got %d", len(moves))
+	}
+	if moves[0].Pass {
+		t.Fatal("expected first move not to be a pass")
+	}
+	if !moves[1].Pass || moves[1].Color != "white" {
+		t.Fatalf("expected second move to be a white pass, got %+v", moves[1])
+	}
+}
+
+func TestCreateAIGame(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	err := repo.CreateAIGame("test-ai-1", "host-secret", "mcts", 3, 5000, 0)
+	if err != nil {
+		t.Fatalf("failed to create AI game: %v", err)
+	}
+
+	game, err := repo.GetGame("test-ai-1")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	if game.AILevel == nil || *game.AILevel != 3 {
+		t.Fatalf("expected ai_level 3, got %v", game.AILevel)
+	}
+	if game.AIEngine == nil || *game.AIEngine != "mcts" {
+		t.Fatalf("expected ai_engine mcts, got %v", game.AIEngine)
+	}
+	if game.AIPlayouts == nil || *game.AIPlayouts != 5000 {
+		t.Fatalf("expected ai_playouts 5000, got %v", game.AIPlayouts)
+	}
+	if game.AITimeLimitMS != nil {
+		t.Fatalf("expected ai_time_limit_ms to be NULL, got %v", *game.AITimeLimitMS)
+	}
+
+	err = repo.SetGuestSecret("test-ai-1", "guest-secret")
+	if !errors.Is(err, ErrGuestAlreadyJoined) {
+		t.Fatalf("expected guests to be refused in AI games, got %v", err)
+	}
+}
+
+func TestGetGame_NotFound(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	_, err := repo.GetGame("missing-play-id")
+	if !errors.Is(err, ErrGameNotFound) {
+		t.Fatalf("expected ErrGameNotFound, got %v", err)
+	}
+}
+
+func TestSetPrivate(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	if err := repo.CreateGameWithSecret("test-play-id-private", "host-secret"); err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	game, _ := repo.GetGame("test-play-id-private")
+	if game.Private {
+		t.Fatal("expected a new game to be public")
+	}
+
+	if err := repo.SetPrivate("test-play-id-private"); err != nil {
+		t.Fatalf("failed to set private: %v", err)
+	}
+	game, _ = repo.GetGame("test-play-id-private")
+	if !game.Private {
+		t.Fatal("expected game to be private")
+	}
+}
+
+func TestSetStartPosition(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	if err := repo.CreateGameWithSecret("test-play-id-position", "host-secret"); err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	game, _ := repo.GetGame("test-play-id-position")
+	if game.StartPosition != nil {
+		t.Fatalf("expected a new game to use the standard setup, got %s", *game.StartPosition)
+	}
+
+	position := "-OOOOOOX--------------------------------------------------------X"
+	if err := repo.SetStartPosition("test-play-id-position", position); err != nil {
+		t.Fatalf("failed to set start position: %v", err)
+	}
+	game, _ = repo.GetGame("test-play-id-position")
+	if game.StartPosition == nil || *game.StartPosition != position {
+		t.Fatalf("expected %s, got %v", position, game.StartPosition)
+	}
+}
+
+func TestSpectators(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	if err := repo.CreateGame("test-play-id-spec"); err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	if err := repo.AddSpectator("test-play-id-spec", "token-1"); err != nil {
+		t.Fatalf("failed to add spectator: %v", err)
+	}
+	if err := repo.AddSpectator("test-play-id-spec", "token-2"); err != nil {
+		t.Fatalf("failed to add spectator: %v", err)
+	}
+
+	ok, err := repo.IsSpectator("test-play-id-spec", "token-1")
+	if err != nil || !ok {
+		t.Fatalf("expected token-1 to be a spectator, got %v (%v)", ok, err)
+	}
+	ok, err = repo.IsSpectator("test-play-id-spec", "unknown")
+	if err != nil || ok {
+		t.Fatalf("expected unknown token to be rejected, got %v (%v)", ok, err)
+	}
+}
+
+func TestSetDrawOffer(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	if err := repo.CreateGameWithSecret("test-draw-1", "host-secret"); err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+
+	black := "black"
+	if err := repo.SetDrawOffer("test-draw-1", &black); err != nil {
+		t.Fatalf("failed to offer draw: %v", err)
+	}
+	game, err := repo.GetGame("test-draw-1")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	if game.DrawOffer == nil || *game.DrawOffer != "black" {
+		t.Fatalf("expected draw offer from black, got %v", game.DrawOffer)
+	}
+
+	if err := repo.EndGame("test-draw-1", 2, 2, "draw", "agreement"); err != nil {
+		t.Fatalf("failed to end game: %v", err)
+	}
+	game, err = repo.GetGame("test-draw-1")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	if game.DrawOffer != nil {
+		t.Fatalf("expected draw offer to be cleared, got %v", *game.DrawOffer)
+	}
+	if game.Termination == nil || *game.Termination != "agreement" {
+		t.Fatalf("expected termination agreement, got %v", game.Termination)
+	}
+}
+
+func TestSetTimeControl(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	if err := repo.CreateGameWithSecret("test-clock-1", "host-secret"); err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	if err := repo.CreateGameWithSecret("test-clock-2", "host-secret"); err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	if err := repo.SetTimeControl("test-clock-1", 300000, 2000, 0); err != nil {
+		t.Fatalf("failed to set time control: %v", err)
+	}
+
+	game, err := repo.GetGame("test-clock-1")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	if game.ClockBaseMS == nil || *game.ClockBaseMS != 300000 {
+		t.Fatalf("expected clock_base_ms 300000, got %v", game.ClockBaseMS)
+	}
+	if game.ClockIncrementMS == nil || *game.ClockIncrementMS != 2000 {
+		t.Fatalf("expected clock_increment_ms 2000, got %v", game.ClockIncrementMS)
+	}
+	if game.ClockMoveMS != nil {
+		t.Fatalf("expected clock_move_ms to be NULL, got %v", *game.ClockMoveMS)
+	}
+
+	games, err := repo.GetTimedGames()
+	if err != nil {
+		t.Fatalf("failed to get timed games: %v", err)
+	}
+	if len(games) != 1 || games[0].PlayID != "test-clock-1" {
+		t.Fatalf("expected only test-clock-1 to be timed, got %+v", games)
+	}
+}
+
+func TestTakeBack(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	if err := repo.CreateGameWithSecret("test-takeback-1", "host-secret"); err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	if err := repo.RecordMove("test-takeback-1", "black", 2, 3, 1); err != nil {
+		t.Fatalf("failed to record move: %v", err)
+	}
+	if err := repo.RecordMove("test-takeback-1", "white", 2, 2, 2); err != nil {
+		t.Fatalf("failed to record move: %v", err)
+	}
+	if err := repo.RecordMove("test-takeback-1", "black", 2, 1, 3); err != nil {
+		t.Fatalf("failed to record move: %v", err)
+	}
+	black := "black"
+	if err := repo.SetTakebackRequest("test-takeback-1", &black); err != nil {
+		t.Fatalf("failed to request takeback: %v", err)
+	}
+
+	if err := repo.TakeBack("test-takeback-1", 3, "black"); err != nil {
+		t.Fatalf("failed to take back: %v", err)
+	}
+
+	moves, err := repo.GetMovesAfter("test-takeback-1", 0)
+	if err != nil {
+		t.Fatalf("failed to get moves: %v", err)
+	}
+	if len(moves) != 2 {
+		t.Fatalf("expected 2 moves left, got %d", len(moves))
+	}
+	game, err := repo.GetGame("test-takeback-1")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	if game.TakebackRequest != nil {
+		t.Fatalf("expected takeback request to be cleared, got %v", *game.TakebackRequest)
+	}
+	if game.ClockResumedAt == nil || game.ClockResumedAt.Before(moves[1].CreatedAt) {
+		t.Fatalf("expected the clocks to restart after the last move left, got %v", game.ClockResumedAt)
+	}
+
+	if game.Takebacks != 1 {
+		t.Fatalf("expected 1 takeback, got %d", game.Takebacks)
+	}
+
+	first, ok, err := repo.GetFirstRevertedMoveOrder("test-takeback-1", 5, 0)
+	if err != nil {
+		t.Fatalf("failed to get reverted moves: %v", err)
+	}
+	if !ok || first != 3 {
+		t.Fatalf("expected move 3 to have been taken back, got %d (%v)", first, ok)
+	}
+	if _, ok, _ := repo.GetFirstRevertedMoveOrder("test-takeback-1", 2, 0); ok {
+		t.Fatal("expected no reverted move up to 2")
+	}
+	if _, ok, _ := repo.GetFirstRevertedMoveOrder("test-takeback-1", 5, 1); ok {
+		t.Fatal("expected no move taken back after the first takeback")
+	}
+
+	// the move_order is free again
+	if err := repo.RecordMove("test-takeback-1", "black", 4, 5, 3); err != nil {
+		t.Fatalf("failed to record move after takeback: %v", err)
+	}
+}
+
+func TestCreateRematch(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	if err := repo.CreateGameWithSecret("test-rematch-1", "host-secret"); err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	if err := repo.SetGuestSecret("test-rematch-1", "guest-secret"); err != nil {
+		t.Fatalf("failed to set guest secret: %v", err)
+	}
+	if err := repo.SetTimeControl("test-rematch-1", 60000, 0, 0); err != nil {
+		t.Fatalf("failed to set time control: %v", err)
+	}
+	if err := repo.EndGame("test-rematch-1", 40, 24, "black_win", "normal"); err != nil {
+		t.Fatalf("failed to end game: %v", err)
+	}
+
+	if err := repo.CreateRematch("test-rematch-2", "test-rematch-1", "new-host", "new-guest"); !errors.Is(err, ErrNoRematchOffer) {
+		t.Fatalf("expected ErrNoRematchOffer, got %v", err)
+	}
+	white := "white"
+	if err := repo.SetRematchOffer("test-rematch-1", &white); err != nil {
+		t.Fatalf("failed to offer rematch: %v", err)
+	}
+	if err := repo.CreateRematch("test-rematch-2", "test-rematch-1", "new-host", "new-guest"); err != nil {
+		t.Fatalf("failed to create rematch: %v", err)
+	}
+
+	rematch, err := repo.GetRematch("test-rematch-1")
+	if err != nil {
+		t.Fatalf("failed to get rematch: %v", err)
+	}
+	if rematch.PlayID != "test-rematch-2" || rematch.HostSecret == nil || *rematch.HostSecret != "new-host" {
+		t.Fatalf("expected test-rematch-2 hosted with new-host, got %+v", rematch)
+	}
+	if rematch.ClockBaseMS == nil || *rematch.ClockBaseMS != 60000 {
+		t.Fatalf("expected the time control to be copied, got %v", rematch.ClockBaseMS)
+	}
+	if rematch.SeriesID == nil || *rematch.SeriesID != "test-rematch-1" {
+		t.Fatalf("expected series test-rematch-1, got %v", rematch.SeriesID)
+	}
+
+	games, err := repo.GetSeries("test-rematch-1")
+	if err != nil {
+		t.Fatalf("failed to get series: %v", err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games in the series, got %d", len(games))
+	}
+	for _, g := range games {
+		if g.RematchOffer != nil {
+			t.Fatalf("expected the rematch offer to be cleared, got %v", *g.RematchOffer)
+		}
+	}
+}
+
+func TestAbandonedGames(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	for _, playID := range []string{"test-idle-1", "test-idle-2"} {
+		if err := repo.CreateGameWithSecret(playID, "host-secret"); err != nil {
+			t.Fatalf("failed to create game: %v", err)
+		}
+	}
+	if err := repo.RecordMove("test-idle-1", "black", 2, 3, 1); err != nil {
+		t.Fatalf("failed to record move: %v", err)
+	}
+	if _, err := db.Exec("UPDATE games SET updated_at = NOW() - INTERVAL 2 HOUR"); err != nil {
+		t.Fatalf("failed to age games: %v", err)
+	}
+	if _, err := db.Exec("UPDATE moves SET created_at = NOW() - INTERVAL 2 HOUR"); err != nil {
+		t.Fatalf("failed to age moves: %v", err)
+	}
+	if err := repo.RecordMove("test-idle-2", "black", 2, 3, 1); err != nil {
+		t.Fatalf("failed to record move: %v", err)
+	}
+
+	games, err := repo.GetInactiveGames(time.Hour)
+	if err != nil {
+		t.Fatalf("failed to get inactive games: %v", err)
+	}
+	if len(games) != 1 || games[0].PlayID != "test-idle-1" {
+		t.Fatalf("expected only test-idle-1 to be inactive, got %+v", games)
+	}
+
+	if err := repo.EndGame("test-idle-1", 4, 1, "abandoned", "abandoned"); err != nil {
+		t.Fatalf("failed to end game: %v", err)
+	}
+	if n, err := repo.PurgeAbandonedGames(time.Hour, true); err != nil || n != 0 {
+		t.Fatalf("expected a freshly abandoned game to be kept, got %d, %v", n, err)
+	}
+	if _, err := db.Exec("UPDATE games SET updated_at = NOW() - INTERVAL 2 HOUR WHERE play_id = 'test-idle-1'"); err != nil {
+		t.Fatalf("failed to age game: %v", err)
+	}
+	if n, err := repo.PurgeAbandonedGames(time.Hour, true); err != nil || n != 1 {
+		t.Fatalf("expected 1 game to be cleaned up, got %d, %v", n, err)
+	}
+
+	if _, err := repo.GetGame("test-idle-1"); !errors.Is(err, ErrGameNotFound) {
+		t.Fatalf("expected the game to be gone, got %v", err)
+	}
+	var archived int
+	if err := db.QueryRow("SELECT COUNT(*) FROM archived_moves WHERE play_id = 'test-idle-1'").Scan(&archived); err != nil {
+		t.Fatalf("failed to count archived moves: %v", err)
+	}
+	if archived != 1 {
+		t.Fatalf("expected 1 archived move, got %d", archived)
+	}
+}
+
+func TestImportGame(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	blackCount, whiteCount, result, termination, player := 0, 14, "white_win", "normal", "alice"
+	game := &model.Game{PlayID: "test-import-1", BlackCount: &blackCount, WhiteCount: &whiteCount, Result: &result, Termination: &termination, BlackPlayer: &player}
+	moves := []model.Move{
+		{Color: "black", Col: 4, Row: 5, MoveOrder: 1},
+		{Color: "white", Col: -1, Row: -1, MoveOrder: 2, Pass: true},
+	}
+	if err := repo.ImportGame(game, moves); err != nil {
+		t.Fatalf("failed to import game: %v", err)
+	}
+
+	got, err := repo.GetGame("test-import-1")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	if got.Result == nil || *got.Result != "white_win" || got.HostSecret != nil {
+		t.Fatalf("expected a finished white_win without secrets, got %+v", got)
+	}
+	if got.BlackPlayer == nil || *got.BlackPlayer != "alice" || got.WhitePlayer != nil {
+		t.Fatalf("expected alice to play black, got %v and %v", got.BlackPlayer, got.WhitePlayer)
+	}
+	stored, err := repo.GetMovesAfter("test-import-1", 0)
+	if err != nil {
+		t.Fatalf("failed to get moves: %v", err)
+	}
+	if len(stored) != 2 || !stored[1].Pass || stored[1].Color != "white" {
+		t.Fatalf("expected a move and a white pass, got %+v", stored)
+	}
+}
+
+func TestImportReferenceGames(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	defer db.Close()
+	defer testutil.CleanupTestDB(t, db)
+
+	repo := NewMySQLRepository(db)
+
+	player := "alice"
+	games := []model.ReferenceGame{
+		{Source: "WTH_2004.wtb", SourceIndex: 1, Year: 2004, BlackPlayer: &player, BlackScore: 0, Transcript: "e6f6f5d6e7f8f7f4c6b6"},
+		{Source: "WTH_2004.wtb", SourceIndex: 2, Year: 2004, BlackScore: 33, TheoreticalScore: 32, Transcript: "f5d6c3"},
+	}
+	added, err := repo.ImportReferenceGames(games)
+	if err != nil {
+		t.Fatalf("failed to import reference games: %v", err)
+	}
+	if added != 2 {
+		t.Fatalf("expected 2 games added, got %d", added)
+	}
+
+	// importing the same file again adds nothing
+	added, err = repo.ImportReferenceGames(games)
+	if err != nil {
+		t.Fatalf("failed to import reference games again: %v", err)
+	}
+	if added != 0 {
+		t.Fatalf("expected no games added, got %d", added)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM games").Scan(&count); err != nil {
+		t.Fatalf("failed to count games: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected reference games to stay out of games, got %d", count)
+	}
+}
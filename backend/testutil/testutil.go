@@ -24,6 +24,11 @@ func SetupTestDB(t *testing.T) *sql.DB {
 
 func CleanupTestDB(t *testing.T, db *sql.DB) {
 	t.Helper()
+	db.Exec("DELETE FROM spectators")
+	db.Exec("DELETE FROM reverted_moves")
 	db.Exec("DELETE FROM moves")
 	db.Exec("DELETE FROM games")
+	db.Exec("DELETE FROM archived_moves")
+	db.Exec("DELETE FROM archived_games")
+	db.Exec("DELETE FROM reference_games")
 }
@@ -0,0 +1,70 @@
+// Package transcript reads and writes games in the standard Othello
+// transcript notation such as "f5d6c3d3c4": the squares played in order, each
+// a column letter a-h followed by a row digit 1-8. Passes are not written;
+// they follow from the rules.
+package transcript
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// Move is a move of a transcript game. Passes have Pass set and no square.
+type Move struct {
+	Color    board.Color
+	Col, Row int
+	Pass     bool
+}
+
+// Parse replays a transcript from the initial position, checking every move
+// against the rules and inserting the passes it implies, including one due
+// right after the last move. Letters may be in either case and whitespace is
+// ignored. It returns the moves and the resulting game.
+func Parse(s string) ([]Move, *board.Game, error) {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+	if len(s)%2 != 0 {
+		return nil, nil, fmt.Errorf("malformed transcript: odd length %d", len(s))
+	}
+
+	g := board.NewGame()
+	var moves []Move
+	for i := 0; i < len(s); i += 2 {
+		n := i/2 + 1
+		p, ok := board.ParseSquare(s[i : i+2])
+		if !ok {
+			return nil, nil, fmt.Errorf("move %d: malformed square %q", n, s[i:i+2])
+		}
+		if g.IsOver() {
+			return nil, nil, fmt.Errorf("move %d (%s): the game is already over", n, s[i:i+2])
+		}
+		turn := g.Turn
+		if err := g.Play(turn, p.Col, p.Row); err != nil {
+			return nil, nil, fmt.Errorf("move %d (%s): illegal move for %s", n, s[i:i+2], turn)
+		}
+		moves = append(moves, Move{Color: turn, Col: p.Col, Row: p.Row})
+		if g.MustPass() {
+			moves = append(moves, Move{Color: g.Turn, Col: -1, Row: -1, Pass: true})
+			g.Pass(g.Turn)
+		}
+	}
+	return moves, g, nil
+}
+
+// Format writes the transcript of a game's moves.
+func Format(moves []Move) string {
+	var sb strings.Builder
+	for _, m := range moves {
+		if !m.Pass {
+			sb.WriteString(board.Position{Col: m.Col, Row: m.Row}.String())
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// passGame is a full game in which black has to pass after the eighth move.
+const passGame = "d3c3f5d2d1e1b2c1a3a1d6c7b3c6g6e3f4g3f6g5e6e7g4h6e8d8f7c2h2e2f3h3b8h4f8d7c5c4g7a4h5b6b4h1b5f2g2b7a8f1a2a6g1a5b1g8c8h8h7a7"
+
+func TestParse(t *testing.T) {
+	moves, g, err := Parse("f5d6c3d3c4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(moves) != 5 {
+		t.Fatalf("expected 5 moves, got %d", len(moves))
+	}
+	if moves[0] != (Move{Color: board.Black, Col: 5, Row: 4}) || moves[1].Color != board.White {
+		t.Fatalf("expected black f5 then white, got %+v", moves[:2])
+	}
+	if g.IsOver() || g.Turn != board.White {
+		t.Fatalf("expected white to move, got %v", g.Turn)
+	}
+}
+
+func TestParse_InsertsPasses(t *testing.T) {
+	moves, g, err := Parse(passGame)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !moves[8].Pass || moves[8].Color != board.Black {
+		t.Fatalf("expected black to pass after the eighth move, got %+v", moves[8])
+	}
+	if moves[9].Color != board.White || moves[9].Pass {
+		t.Fatalf("expected white to play again, got %+v", moves[9])
+	}
+	if !g.IsOver() || g.Board.Count(board.Black) != 23 || g.Board.Count(board.White) != 41 {
+		t.Fatalf("expected the game to end 23-41, got over=%v %d-%d", g.IsOver(), g.Board.Count(board.Black), g.Board.Count(board.White))
+	}
+	if got := Format(moves); got != passGame {
+		t.Fatalf("expected the transcript back, got %s", got)
+	}
+}
+
+func TestParse_Normalizes(t *testing.T) {
+	moves, _, err := Parse(" F5 d6\nC3 ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := Format(moves); got != "f5d6c3" {
+		t.Fatalf("expected f5d6c3, got %s", got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		transcript, want string
+	}{
+		{"f5d", "odd length"},
+		{"f5z9", "move 2: malformed square"},
+		{"f5f5", "move 2 (f5): illegal move for white"},
+		{"a1", "move 1 (a1): illegal move for black"},
+		{"e6f6f5d6e7f8f7f4c6b6c5", "move 11 (c5): the game is already over"},
+	}
+	for _, tt := range tests {
+		_, _, err := Parse(tt.transcript)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tt.transcript, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package wthor decodes the binary WTHOR database files published by the
+// French Othello Federation: games (.wtb), players (.jou) and tournaments
+// (.trn). Every file starts with a 16-byte header; the integers are little
+// endian and names are ISO-8859-1.
+package wthor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dog-nose/othello-backend/board"
+	"github.com/dog-nose/othello-backend/transcript"
+)
+
+const (
+	headerSize     = 16
+	gameSize       = 68
+	playerSize     = 20
+	tournamentSize = 26
+	maxMoves       = 60
+)
+
+// Header is the file header. Games is the number of games of a .wtb file,
+// Records the number of names of a .jou or .trn file.
+type Header struct {
+	Games   int
+	Records int
+	// Year is the year the games were played in.
+	Year int
+	// BoardSize is 8, or 0 which also means 8.
+	BoardSize int
+	// Solitaire files hold puzzle games rather than played ones.
+	Solitaire bool
+}
+
+// Game is a game record. Players and the tournament are indexes into the
+// .jou and .trn files.
+type Game struct {
+	Tournament, Black, White int
+	// BlackScore is black's disc count at the end, with empty squares given
+	// to the winner; TheoreticalScore is the best black could have got from
+	// the depth given in the header on.
+	BlackScore, TheoreticalScore int
+	// Moves is the transcript of the game, without passes.
+	Moves string
+}
+
+func readHeader(r io.Reader) (*Header, error) {
+	var b [headerSize]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	return &Header{
+		Games:     int(binary.LittleEndian.Uint32(b[4:8])),
+		Records:   int(binary.LittleEndian.Uint16(b[8:10])),
+		Year:      int(binary.LittleEndian.Uint16(b[10:12])),
+		BoardSize: int(b[12]),
+		Solitaire: b[13] == 1,
+	}, nil
+}
+
+// ReadGames reads a .wtb file.
+func ReadGames(r io.Reader) (*Header, []Game, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if h.BoardSize != 0 && h.BoardSize != 8 {
+		return nil, nil, fmt.Errorf("unsupported board size %d", h.BoardSize)
+	}
+	if h.Solitaire {
+		return nil, nil, fmt.Errorf("solitaire files are not supported")
+	}
+
+	games := make([]Game, 0, h.Games)
+	var b [gameSize]byte
+	for i := 0; i < h.Games; i++ {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, nil, fmt.Errorf("game %d: %w", i+1, err)
+		}
+		g, err := decodeGame(b[:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("game %d: %w", i+1, err)
+		}
+		games = append(games, g)
+	}
+	return h, games, nil
+}
+
+// decodeGame decodes a game record. Moves are stored as 10*row + col with
+// both counted from 1, so f5 is 56; a zero ends a game shorter than 60 moves.
+func decodeGame(b []byte) (Game, error) {
+	g := Game{
+		Tournament:       int(binary.LittleEndian.Uint16(b[0:2])),
+		Black:            int(binary.LittleEndian.Uint16(b[2:4])),
+		White:            int(binary.LittleEndian.Uint16(b[4:6])),
+		BlackScore:       int(b[6]),
+		TheoreticalScore: int(b[7]),
+	}
+	var sb strings.Builder
+	for _, m := range b[8 : 8+maxMoves] {
+		if m == 0 {
+			break
+		}
+		row, col := int(m/10), int(m%10)
+		if row < 1 || row > 8 || col < 1 || col > 8 {
+			return Game{}, fmt.Errorf("malformed move %d", m)
+		}
+		sb.WriteString(board.Position{Col: col - 1, Row: row - 1}.String())
+	}
+	g.Moves = sb.String()
+	return g, nil
+}
+
+// ReadPlayers reads a .jou file.
+func ReadPlayers(r io.Reader) ([]string, error) {
+	return readNames(r, playerSize)
+}
+
+// ReadTournaments reads a .trn file.
+func ReadTournaments(r io.Reader) ([]string, error) {
+	return readNames(r, tournamentSize)
+}
+
+func readNames(r io.Reader, size int) ([]string, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, h.Records)
+	b := make([]byte, size)
+	for i := 0; i < h.Records; i++ {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		names = append(names, latin1(b))
+	}
+	return names, nil
+}
+
+// latin1 decodes a NUL padded ISO-8859-1 string.
+func latin1(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c == 0 {
+			break
+		}
+		sb.WriteRune(rune(c))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// Verify replays a game under the rules and, if it was played out, checks
+// its recorded score.
+func Verify(g Game) error {
+	_, game, err := transcript.Parse(g.Moves)
+	if err != nil {
+		return err
+	}
+	if !game.IsOver() {
+		return nil
+	}
+	if score := finalScore(game.Board.Count(board.Black), game.Board.Count(board.White)); score != g.BlackScore {
+		return fmt.Errorf("recorded score %d does not match the final position (%d)", g.BlackScore, score)
+	}
+	return nil
+}
+
+// finalScore is black's disc count with the empty squares given to the
+// winner, or shared on a draw.
+func finalScore(black, white int) int {
+	empty := 64 - black - white
+	switch {
+	case black > white:
+		return black + empty
+	case black < white:
+		return black
+	}
+	return black + empty/2
+}
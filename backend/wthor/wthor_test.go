@@ -0,0 +1,153 @@
+package wthor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/dog-nose/othello-backend/board"
+)
+
+// passGame is a full game in which black has to pass after the eighth move;
+// white wins 23-41.
+const passGame = "d3c3f5d2d1e1b2c1a3a1d6c7b3c6g6e3f4g3f6g5e6e7g4h6e8d8f7c2h2e2f3h3b8h4f8d7c5c4g7a4h5b6b4h1b5f2g2b7a8f1a2a6g1a5b1g8c8h8h7a7"
+
+func header(games, records, year int) []byte {
+	b := make([]byte, headerSize)
+	b[0], b[1], b[2], b[3] = 20, 24, 1, 15
+	binary.LittleEndian.PutUint32(b[4:8], uint32(games))
+	binary.LittleEndian.PutUint16(b[8:10], uint16(records))
+	binary.LittleEndian.PutUint16(b[10:12], uint16(year))
+	b[12] = 8
+	return b
+}
+
+// record encodes a game with the moves of a transcript.
+func record(t *testing.T, tournament, black, white, score, theoretical int, moves string) []byte {
+	t.Helper()
+	b := make([]byte, gameSize)
+	binary.LittleEndian.PutUint16(b[0:2], uint16(tournament))
+	binary.LittleEndian.PutUint16(b[2:4], uint16(black))
+	binary.LittleEndian.PutUint16(b[4:6], uint16(white))
+	b[6], b[7] = byte(score), byte(theoretical)
+	for i := 0; i < len(moves); i += 2 {
+		p, ok := board.ParseSquare(moves[i : i+2])
+		if !ok {
+			t.Fatalf("malformed square %s", moves[i:i+2])
+		}
+		b[8+i/2] = byte(10*(p.Row+1) + p.Col + 1)
+	}
+	return b
+}
+
+func TestReadGames(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(header(2, 0, 2004))
+	buf.Write(record(t, 3, 10, 11, 23, 25, passGame))
+	buf.Write(record(t, 3, 12, 10, 0, 0, "f5d6c3"))
+
+	h, games, err := ReadGames(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.Games != 2 || h.Year != 2004 {
+		t.Fatalf("expected 2 games from 2004, got %+v", h)
+	}
+	g := games[0]
+	if g.Tournament != 3 || g.Black != 10 || g.White != 11 || g.BlackScore != 23 || g.TheoreticalScore != 25 {
+		t.Fatalf("expected the first game's header, got %+v", g)
+	}
+	if g.Moves != passGame {
+		t.Fatalf("expected %s, got %s", passGame, g.Moves)
+	}
+	if games[1].Moves != "f5d6c3" {
+		t.Fatalf("expected f5d6c3, got %s", games[1].Moves)
+	}
+}
+
+func TestReadGames_Errors(t *testing.T) {
+	malformed := record(t, 0, 0, 0, 0, 0, "f5")
+	malformed[9] = 90
+	solitaire := header(0, 0, 2004)
+	solitaire[13] = 1
+	tenByTen := header(0, 0, 2004)
+	tenByTen[12] = 10
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"short header", []byte{20, 24}, "reading header"},
+		{"truncated", append(header(2, 0, 2004), record(t, 0, 0, 0, 0, 0, "f5")...), "game 2"},
+		{"malformed move", append(header(1, 0, 2004), malformed...), "malformed move 90"},
+		{"solitaire", solitaire, "solitaire"},
+		{"board size", tenByTen, "board size 10"},
+	}
+	for _, tt := range tests {
+		_, _, err := ReadGames(bytes.NewReader(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestReadPlayers(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(header(0, 2, 0))
+	name := make([]byte, playerSize)
+	copy(name, "Tamenori Hideshi")
+	buf.Write(name)
+	name = make([]byte, playerSize)
+	copy(name, []byte{'L', 'e', 'v', 'y', ' ', 'J', 0xe9, 'r', 0xf4, 'm', 'e'})
+	buf.Write(name)
+
+	players, err := ReadPlayers(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(players) != 2 || players[0] != "Tamenori Hideshi" || players[1] != "Levy Jérôme" {
+		t.Fatalf("expected the two players, got %q", players)
+	}
+}
+
+func TestReadTournaments(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(header(0, 1, 0))
+	name := make([]byte, tournamentSize)
+	copy(name, "Championnat du Monde")
+	buf.Write(name)
+
+	tournaments, err := ReadTournaments(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tournaments) != 1 || tournaments[0] != "Championnat du Monde" {
+		t.Fatalf("expected the world championship, got %q", tournaments)
+	}
+
+	if _, err := ReadTournaments(bytes.NewReader(header(0, 2, 0))); err == nil {
+		t.Fatal("expected an error for missing records")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		game Game
+		ok   bool
+	}{
+		{"played out", Game{BlackScore: 23, Moves: passGame}, true},
+		{"wrong score", Game{BlackScore: 41, Moves: passGame}, false},
+		// white wins 0-14 with the empty squares
+		{"wipe out", Game{BlackScore: 0, Moves: "e6f6f5d6e7f8f7f4c6b6"}, true},
+		{"unfinished", Game{BlackScore: 40, Moves: "f5d6c3"}, true},
+		{"illegal move", Game{Moves: "f5f5"}, false},
+	}
+	for _, tt := range tests {
+		if err := Verify(tt.game); (err == nil) != tt.ok {
+			t.Fatalf("%s: expected ok=%v, got %v", tt.name, tt.ok, err)
+		}
+	}
+}
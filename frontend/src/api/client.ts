@@ -1,7 +1,17 @@
 const API_BASE = '/api';
 
-export async function startGame(): Promise<{ play_id: string; host_secret: string }> {
-  const res = await fetch(`${API_BASE}/start-game`, { method: 'POST' });
+// position is a 64-character X/O/- board from a1 to h8 followed by the side to move (X or O).
+export async function startGame(position?: string): Promise<{ play_id: string; host_secret: string }> {
+  const init: RequestInit = { method: 'POST' };
+  if (position) {
+    init.headers = { 'Content-Type': 'application/json' };
+    init.body = JSON.stringify({ position });
+  }
+  const res = await fetch(`${API_BASE}/start-game`, init);
+  if (!res.ok) {
+    const error = await res.json();
+    throw new Error(error.message || 'Failed to start game');
+  }
   return res.json();
 }
 
@@ -18,15 +28,75 @@ export async function placeStone(playId: string, color: string, col: number, row
   return res.json();
 }
 
-export async function endGame(playId: string, blackCount: number, whiteCount: number): Promise<{ success: boolean; message?: string }> {
-  const res = await fetch(`${API_BASE}/end-game`, {
+async function gameAction(path: string, playId: string, secret: string): Promise<{ success: boolean; message?: string }> {
+  const res = await fetch(`${API_BASE}/${path}`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ play_id: playId, black_count: blackCount, white_count: whiteCount }),
+    body: JSON.stringify({ play_id: playId, secret }),
   });
   return res.json();
 }
 
+export function resignGame(playId: string, secret: string) {
+  return gameAction('resign', playId, secret);
+}
+
+export function offerDraw(playId: string, secret: string) {
+  return gameAction('offer-draw', playId, secret);
+}
+
+export function acceptDraw(playId: string, secret: string) {
+  return gameAction('accept-draw', playId, secret);
+}
+
+export function declineDraw(playId: string, secret: string) {
+  return gameAction('decline-draw', playId, secret);
+}
+
+// Only possible before both players have moved.
+export function abortGame(playId: string, secret: string) {
+  return gameAction('abort-game', playId, secret);
+}
+
+// Takes back the requester's last move and any reply to it once accepted.
+export function requestTakeback(playId: string, secret: string) {
+  return gameAction('request-takeback', playId, secret);
+}
+
+export function acceptTakeback(playId: string, secret: string) {
+  return gameAction('accept-takeback', playId, secret);
+}
+
+export function declineTakeback(playId: string, secret: string) {
+  return gameAction('decline-takeback', playId, secret);
+}
+
+export interface RematchResponse {
+  pending: boolean;
+  play_id?: string;
+  secret?: string;
+  color?: 'black' | 'white';
+}
+
+// Offers a rematch of a finished game, or accepts the opponent's offer. Once
+// the rematch exists, calling it again returns the player's new secret.
+export async function requestRematch(playId: string, secret: string): Promise<RematchResponse> {
+  const res = await fetch(`${API_BASE}/rematch`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ play_id: playId, secret }),
+  });
+  if (!res.ok) {
+    const error = await res.json();
+    throw new Error(error.message || 'Failed to request rematch');
+  }
+  return res.json();
+}
+
+export function declineRematch(playId: string, secret: string) {
+  return gameAction('decline-rematch', playId, secret);
+}
+
 export async function joinGame(playId: string): Promise<{ guest_secret: string }> {
   const res = await fetch(`${API_BASE}/join-game`, {
     method: 'POST',
@@ -46,13 +116,215 @@ export interface PollMovesMove {
   col: number;
   row: number;
   move_order: number;
+  pass: boolean;
+}
+
+export interface ChatMessage {
+  color: string;
+  text: string;
+  sent_at: string;
+}
+
+export interface GameEvent {
+  type: 'move' | 'pass' | 'guest_joined' | 'game_over' | 'chat' | 'draw_offered' | 'draw_declined'
+    | 'takeback_requested' | 'takeback_declined' | 'takeback'
+    | 'rematch_offered' | 'rematch_declined' | 'rematch';
+  play_id: string;
+  move?: PollMovesMove;
+  chat?: ChatMessage;
+  color?: string;
+  result?: string;
+  termination?: 'normal' | 'resignation' | 'agreement' | 'aborted' | 'timeout' | 'abandoned';
+  // Set on takeback: moves after this order were removed, and takebacks is
+  // the new count to resume with.
+  after_move_order?: number;
+  takebacks?: number;
+  rematch_play_id?: string;
+  black_count?: number;
+  white_count?: number;
 }
 
-export async function pollMoves(playId: string, afterMoveOrder: number): Promise<{ moves: PollMovesMove[] }> {
+export async function spectateGame(playId: string, secret?: string): Promise<{ spectator_token: string }> {
+  const body: Record<string, unknown> = { play_id: playId };
+  if (secret) {
+    body.secret = secret;
+  }
+  const res = await fetch(`${API_BASE}/spectate`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(body),
+  });
+  if (!res.ok) {
+    const error = await res.json();
+    throw new Error(error.message || 'Failed to spectate game');
+  }
+  return res.json();
+}
+
+// Private games need a player secret or spectator token to be read.
+function tokenParam(token?: string): string {
+  return token ? `&token=${encodeURIComponent(token)}` : '';
+}
+
+function takebacksParam(takebacks?: number): string {
+  return takebacks === undefined ? '' : `&takebacks=${takebacks}`;
+}
+
+// takebacks is the takeback count the moves up to afterMoveOrder are from, so
+// that the server can rewind a client that missed a takeback; without it the
+// server assumes the game's current count.
+export function openGameSocket(playId: string, afterMoveOrder: number, takebacks?: number, token?: string): WebSocket {
+  const scheme = window.location.protocol === 'https:' ? 'wss' : 'ws';
+  return new WebSocket(`${scheme}://${window.location.host}${API_BASE}/games/${encodeURIComponent(playId)}/ws?after_move_order=${afterMoveOrder}${takebacksParam(takebacks)}${tokenParam(token)}`);
+}
+
+// openGameEvents is the Server-Sent Events alternative to openGameSocket;
+// EventSource resumes through Last-Event-ID on its own.
+export function openGameEvents(playId: string, afterMoveOrder: number, takebacks?: number, token?: string): EventSource {
+  return new EventSource(`${API_BASE}/games/${encodeURIComponent(playId)}/events?after_move_order=${afterMoveOrder}${takebacksParam(takebacks)}${tokenParam(token)}`);
+}
+
+export async function sendChat(playId: string, secret: string, message: string): Promise<{ success: boolean; message?: string }> {
+  const res = await fetch(`${API_BASE}/chat`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ play_id: playId, secret, message }),
+  });
+  return res.json();
+}
+
+// With waitMs the server holds the request until a new move arrives or the wait elapses.
+export async function pollMoves(playId: string, afterMoveOrder: number, takebacks?: number, waitMs?: number, token?: string): Promise<{ moves: PollMovesMove[]; after_move_order?: number; takebacks: number; opening?: string }> {
+  const body: Record<string, unknown> = { play_id: playId, after_move_order: afterMoveOrder, takebacks };
+  if (waitMs) {
+    body.wait_ms = waitMs;
+  }
+  if (token) {
+    body.token = token;
+  }
   const res = await fetch(`${API_BASE}/poll-moves`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ play_id: playId, after_move_order: afterMoveOrder }),
+    body: JSON.stringify(body),
+  });
+  return res.json();
+}
+
+export interface GameStateResponse {
+  play_id: string;
+  start_position?: string;
+  board: ('black' | 'white' | '')[][];
+  side_to_move?: 'black' | 'white';
+  legal_moves: { col: number; row: number }[];
+  black_count: number;
+  white_count: number;
+  move_count: number;
+  passes: PollMovesMove[];
+  result: string | null;
+  termination: string | null;
+  draw_offer: 'black' | 'white' | null;
+  takeback_request: 'black' | 'white' | null;
+  takebacks: number;
+  guest_joined: boolean;
+  // Only known for imported games.
+  black_player?: string;
+  white_player?: string;
+  private: boolean;
+  spectator_count: number;
+  ai_level: number | null;
+  ai_engine: string | null;
+  opening?: string;
+  time_control?: { base_ms?: number; increment_ms?: number; move_ms?: number };
+  // Only present for timed games in progress.
+  clock?: { black_ms: number; white_ms: number; running?: 'black' | 'white' };
+  rematch_offer: 'black' | 'white' | null;
+  rematch_play_id?: string;
+  // Wins are counted for the players holding each color in this game.
+  series?: { id: string; game_number: number; black: number; white: number; draws: number };
+  created_at: string;
+  updated_at: string;
+}
+
+export async function getGame(playId: string, token?: string): Promise<GameStateResponse> {
+  const query = token ? `?token=${encodeURIComponent(token)}` : '';
+  const res = await fetch(`${API_BASE}/games/${encodeURIComponent(playId)}${query}`);
+  if (!res.ok) {
+    const error = await res.json();
+    throw new Error(error.message || 'Failed to get game');
+  }
+  return res.json();
+}
+
+// Transcripts use the standard notation, e.g. "f5d6c3d3c4"; passes are implied.
+export async function getTranscript(playId: string, token?: string): Promise<{ play_id: string; start_position?: string; transcript: string }> {
+  const query = token ? `?token=${encodeURIComponent(token)}` : '';
+  const res = await fetch(`${API_BASE}/games/${encodeURIComponent(playId)}/transcript${query}`);
+  if (!res.ok) {
+    const error = await res.json();
+    throw new Error(error.message || 'Failed to get transcript');
+  }
+  return res.json();
+}
+
+// host_secret is only returned when the imported game is still in progress.
+export async function importGame(transcript: string): Promise<{ play_id: string; host_secret?: string; result: string | null }> {
+  const res = await fetch(`${API_BASE}/import-game`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ transcript }),
   });
+  if (!res.ok) {
+    const error = await res.json();
+    throw new Error(error.message || 'Failed to import game');
+  }
   return res.json();
 }
+
+export async function getGGF(playId: string, token?: string): Promise<{ play_id: string; ggf: string }> {
+  const query = token ? `?token=${encodeURIComponent(token)}` : '';
+  const res = await fetch(`${API_BASE}/games/${encodeURIComponent(playId)}/ggf${query}`);
+  if (!res.ok) {
+    const error = await res.json();
+    throw new Error(error.message || 'Failed to get GGF record');
+  }
+  return res.json();
+}
+
+// boardImageURL is meant for <img src>: the board at moveNumber (the latest by default).
+export function boardImageURL(
+  playId: string,
+  format: 'svg' | 'png',
+  options: { moveNumber?: number; legalMoves?: boolean; token?: string } = {},
+): string {
+  const params = new URLSearchParams();
+  if (options.moveNumber !== undefined) {
+    params.set('move_number', String(options.moveNumber));
+  }
+  if (options.legalMoves) {
+    params.set('legal_moves', 'true');
+  }
+  if (options.token) {
+    params.set('token', options.token);
+  }
+  const query = params.toString();
+  return `${API_BASE}/games/${encodeURIComponent(playId)}/board.${format}${query ? `?${query}` : ''}`;
+}
+
+// replayGIFURL animates a finished game; delayMs is the time each move stays up.
+export function replayGIFURL(
+  playId: string,
+  options: { delayMs?: number; eval?: boolean; token?: string } = {},
+): string {
+  const params = new URLSearchParams();
+  if (options.delayMs !== undefined) {
+    params.set('delay_ms', String(options.delayMs));
+  }
+  if (options.eval) {
+    params.set('eval', 'true');
+  }
+  if (options.token) {
+    params.set('token', options.token);
+  }
+  const query = params.toString();
+  return `${API_BASE}/games/${encodeURIComponent(playId)}/replay.gif${query ? `?${query}` : ''}`;
+}
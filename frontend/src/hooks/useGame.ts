@@ -30,12 +30,6 @@ export function useGame() {
         });
       }
 
-      if (next.isGameOver && prev.playId) {
-        api.endGame(prev.playId, next.blackCount, next.whiteCount).catch(err => {
-          console.error('Failed to end game:', err);
-        });
-      }
-
       return { ...next, playId: prev.playId };
     });
   }, []);
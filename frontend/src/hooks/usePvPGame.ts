@@ -11,6 +11,8 @@ export function usePvPGame() {
 
   const gameStateRef = useRef(gameState);
   const pvpStateRef = useRef(pvpState);
+  // The stones placed so far, to rebuild the board after a takeback
+  const movesRef = useRef<api.PollMovesMove[]>([]);
 
   gameStateRef.current = gameState;
   pvpStateRef.current = pvpState;
@@ -21,12 +23,14 @@ export function usePvPGame() {
       const { play_id, host_secret } = await api.startGame();
       const state = createInitialGameState();
       state.playId = play_id;
+      movesRef.current = [];
       setGameState(state);
       setPvPState({
         role: 'host',
         myColor: 'black',
         secret: host_secret,
         lastKnownMoveOrder: 0,
+        takebacks: 0,
         isWaitingForOpponent: true,
         isMyTurn: true,
       });
@@ -43,12 +47,14 @@ export function usePvPGame() {
       const { guest_secret } = await api.joinGame(playId);
       const state = createInitialGameState();
       state.playId = playId;
+      movesRef.current = [];
       setGameState(state);
       setPvPState({
         role: 'guest',
         myColor: 'white',
         secret: guest_secret,
         lastKnownMoveOrder: 0,
+        takebacks: 0,
         isWaitingForOpponent: false,
         isMyTurn: false,
       });
@@ -69,6 +75,14 @@ export function usePvPGame() {
       if (next === prev) return prev;
 
       const newMoveOrder = currentPvP.lastKnownMoveOrder + 1;
+      movesRef.current.push({
+        play_id: prev.playId ?? '',
+        color: prev.currentPlayer,
+        col,
+        row,
+        move_order: newMoveOrder,
+        pass: false,
+      });
 
       if (prev.playId) {
         api.placeStone(prev.playId, prev.currentPlayer, col, row, currentPvP.secret).catch(err => {
@@ -76,12 +90,6 @@ export function usePvPGame() {
         });
       }
 
-      if (next.isGameOver && prev.playId) {
-        api.endGame(prev.playId, next.blackCount, next.whiteCount).catch(err => {
-          console.error('Failed to end game:', err);
-        });
-      }
-
       // Determine if it's still my turn (pass case)
       const stillMyTurn = !next.isGameOver && next.currentPlayer === currentPvP.myColor;
 
@@ -95,67 +103,94 @@ export function usePvPGame() {
     });
   }, []);
 
-  // Polling for opponent moves
+  // Live opponent moves; a reconnect resumes after the last known move
+  const playId = gameState.playId;
   useEffect(() => {
-    if (!isStarted || !pvpState) return;
+    if (!isStarted || !playId) return;
 
-    const interval = setInterval(async () => {
+    const handleEvent = (event: api.GameEvent) => {
       const currentPvP = pvpStateRef.current;
       const currentGame = gameStateRef.current;
+      if (!currentPvP) return;
 
-      if (!currentPvP || !currentGame.playId) return;
-      if (currentPvP.isMyTurn && !currentPvP.isWaitingForOpponent) return;
-      if (currentGame.isGameOver) return;
-
-      try {
-        const { moves } = await api.pollMoves(currentGame.playId, currentPvP.lastKnownMoveOrder);
-
-        if (moves.length === 0) {
-          // Check if opponent has joined (host waiting)
-          if (currentPvP.isWaitingForOpponent && currentPvP.role === 'host') {
-            // We can't directly check if guest joined, but once they make a move we'll know
-            // For now, keep polling
+      if (event.type === 'guest_joined') {
+        setPvPState(prev => prev ? { ...prev, isWaitingForOpponent: false } : prev);
+        return;
+      }
+      if (event.type === 'takeback' && event.after_move_order !== undefined) {
+        // Drop the moves taken back and replay the rest from the start
+        const after = event.after_move_order;
+        movesRef.current = movesRef.current.filter(m => m.move_order <= after);
+        let rebuilt = createInitialGameState();
+        for (const m of movesRef.current) {
+          if (!m.pass) {
+            rebuilt = applyOpponentMove(rebuilt, m.row, m.col, m.color as Color);
           }
-          return;
         }
-
-        // If we're waiting for opponent and we got moves, they've joined
-        let updatedGame = currentGame;
-        let latestMoveOrder = currentPvP.lastKnownMoveOrder;
-
-        for (const move of moves) {
-          const color = move.color as Color;
-          updatedGame = applyOpponentMove(updatedGame, move.row, move.col, color);
-          updatedGame = { ...updatedGame, playId: currentGame.playId };
-          latestMoveOrder = move.move_order;
-        }
-
-        setGameState(updatedGame);
-
-        const isNowMyTurn = !updatedGame.isGameOver && updatedGame.currentPlayer === currentPvP.myColor;
-
+        rebuilt = { ...rebuilt, playId: currentGame.playId };
+        setGameState(rebuilt);
         setPvPState(prev => prev ? {
           ...prev,
-          lastKnownMoveOrder: latestMoveOrder,
-          isMyTurn: isNowMyTurn,
-          isWaitingForOpponent: false,
+          lastKnownMoveOrder: after,
+          takebacks: event.takebacks ?? prev.takebacks,
+          isMyTurn: !rebuilt.isGameOver && rebuilt.currentPlayer === prev.myColor,
         } : prev);
+        return;
+      }
+      if ((event.type !== 'move' && event.type !== 'pass') || !event.move) return;
 
-        if (updatedGame.isGameOver && currentGame.playId) {
-          api.endGame(currentGame.playId, updatedGame.blackCount, updatedGame.whiteCount).catch(err => {
-            console.error('Failed to end game:', err);
-          });
-        }
-      } catch (err) {
-        console.error('Poll error:', err);
+      const move = event.move;
+      if (move.move_order <= currentPvP.lastKnownMoveOrder) return;
+
+      // Passes are applied locally already; own moves were applied in makeMove
+      let updatedGame = currentGame;
+      if (!move.pass && move.color !== currentPvP.myColor) {
+        movesRef.current.push(move);
+        updatedGame = applyOpponentMove(currentGame, move.row, move.col, move.color as Color);
+        updatedGame = { ...updatedGame, playId: currentGame.playId };
+        setGameState(updatedGame);
       }
-    }, 1000);
 
-    return () => clearInterval(interval);
-  }, [isStarted, pvpState]);
+      const isNowMyTurn = !updatedGame.isGameOver && updatedGame.currentPlayer === currentPvP.myColor;
+      setPvPState(prev => prev ? {
+        ...prev,
+        lastKnownMoveOrder: move.move_order,
+        isMyTurn: isNowMyTurn,
+        isWaitingForOpponent: false,
+      } : prev);
+    };
+
+    let socket: WebSocket | null = null;
+    let closed = false;
+    let retry: ReturnType<typeof setTimeout> | undefined;
+
+    const connect = () => {
+      socket = api.openGameSocket(playId, pvpStateRef.current?.lastKnownMoveOrder ?? 0, pvpStateRef.current?.takebacks);
+      socket.onmessage = (msg) => {
+        try {
+          handleEvent(JSON.parse(msg.data) as api.GameEvent);
+        } catch (err) {
+          console.error('Bad game event:', err);
+        }
+      };
+      socket.onclose = () => {
+        if (!closed) {
+          retry = setTimeout(connect, 1000);
+        }
+      };
+    };
+    connect();
+
+    return () => {
+      closed = true;
+      clearTimeout(retry);
+      socket?.close();
+    };
+  }, [isStarted, playId]);
 
   const restart = useCallback(() => {
     setIsStarted(false);
+    movesRef.current = [];
     setGameState(createInitialGameState());
     setPvPState(null);
     setError(null);
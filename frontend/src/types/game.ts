@@ -26,6 +26,8 @@ export interface PvPState {
   myColor: Color;
   secret: string;
   lastKnownMoveOrder: number;
+  // takebacks is the server's takeback count lastKnownMoveOrder is from.
+  takebacks: number;
   isWaitingForOpponent: boolean;
   isMyTurn: boolean;
 }
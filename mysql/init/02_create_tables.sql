@@ -4,11 +4,35 @@ CREATE TABLE IF NOT EXISTS games (
     play_id VARCHAR(36) PRIMARY KEY,
     black_count INT DEFAULT NULL,
     white_count INT DEFAULT NULL,
-    result ENUM('black_win', 'white_win', 'draw') DEFAULT NULL,
+    result ENUM('black_win', 'white_win', 'draw', 'aborted', 'abandoned') DEFAULT NULL,
+    termination ENUM('normal', 'resignation', 'agreement', 'aborted', 'timeout', 'abandoned') DEFAULT NULL,
+    draw_offer ENUM('black', 'white') DEFAULT NULL,
+    takeback_request ENUM('black', 'white') DEFAULT NULL,
+    takebacks INT NOT NULL DEFAULT 0,
     host_secret VARCHAR(36) DEFAULT NULL,
     guest_secret VARCHAR(36) DEFAULT NULL,
+    ai_level TINYINT DEFAULT NULL,
+    ai_engine VARCHAR(16) DEFAULT NULL,
+    ai_playouts INT DEFAULT NULL,
+    ai_time_limit_ms INT DEFAULT NULL,
+    private BOOLEAN NOT NULL DEFAULT FALSE,
+    clock_base_ms INT DEFAULT NULL,
+    clock_increment_ms INT DEFAULT NULL,
+    clock_move_ms INT DEFAULT NULL,
+    clock_resumed_at TIMESTAMP(3) DEFAULT NULL,
+    rematch_of VARCHAR(36) DEFAULT NULL,
+    series_id VARCHAR(36) DEFAULT NULL,
+    rematch_offer ENUM('black', 'white') DEFAULT NULL,
+    black_player VARCHAR(64) DEFAULT NULL,
+    white_player VARCHAR(64) DEFAULT NULL,
+    black_rating DOUBLE DEFAULT NULL,
+    white_rating DOUBLE DEFAULT NULL,
+    played_at DATETIME DEFAULT NULL,
+    start_position CHAR(65) DEFAULT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_rematch_of (rematch_of),
+    INDEX idx_series (series_id)
 );
 
 CREATE TABLE IF NOT EXISTS moves (
@@ -18,22 +42,94 @@ CREATE TABLE IF NOT EXISTS moves (
     col TINYINT NOT NULL,
     `row` TINYINT NOT NULL,
     move_order INT NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    pass BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
     FOREIGN KEY (play_id) REFERENCES games(play_id),
     UNIQUE KEY uk_play_move (play_id, move_order)
 );
 
+CREATE TABLE IF NOT EXISTS reverted_moves (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    play_id VARCHAR(36) NOT NULL,
+    color ENUM('black', 'white') NOT NULL,
+    col TINYINT NOT NULL,
+    `row` TINYINT NOT NULL,
+    move_order INT NOT NULL,
+    pass BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP(3) NOT NULL,
+    requested_by ENUM('black', 'white') NOT NULL,
+    takeback INT NOT NULL DEFAULT 0,
+    reverted_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (play_id) REFERENCES games(play_id),
+    INDEX idx_play_move (play_id, move_order)
+);
+
+CREATE TABLE IF NOT EXISTS spectators (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    play_id VARCHAR(36) NOT NULL,
+    token VARCHAR(36) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (play_id) REFERENCES games(play_id),
+    UNIQUE KEY uk_token (token)
+);
+
+CREATE TABLE IF NOT EXISTS archived_games LIKE games;
+
+CREATE TABLE IF NOT EXISTS archived_moves LIKE moves;
+
+CREATE TABLE IF NOT EXISTS reference_games (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    source VARCHAR(64) NOT NULL,
+    source_index INT NOT NULL,
+    year SMALLINT NOT NULL,
+    tournament VARCHAR(26),
+    black_player VARCHAR(20),
+    white_player VARCHAR(20),
+    black_score TINYINT NOT NULL,
+    theoretical_score TINYINT NOT NULL,
+    transcript VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_source (source, source_index),
+    INDEX idx_transcript (transcript),
+    INDEX idx_black_player (black_player),
+    INDEX idx_white_player (white_player)
+);
+
 USE othello_test;
 
 CREATE TABLE IF NOT EXISTS games (
     play_id VARCHAR(36) PRIMARY KEY,
     black_count INT DEFAULT NULL,
     white_count INT DEFAULT NULL,
-    result ENUM('black_win', 'white_win', 'draw') DEFAULT NULL,
+    result ENUM('black_win', 'white_win', 'draw', 'aborted', 'abandoned') DEFAULT NULL,
+    termination ENUM('normal', 'resignation', 'agreement', 'aborted', 'timeout', 'abandoned') DEFAULT NULL,
+    draw_offer ENUM('black', 'white') DEFAULT NULL,
+    takeback_request ENUM('black', 'white') DEFAULT NULL,
+    takebacks INT NOT NULL DEFAULT 0,
     host_secret VARCHAR(36) DEFAULT NULL,
     guest_secret VARCHAR(36) DEFAULT NULL,
+    ai_level TINYINT DEFAULT NULL,
+    ai_engine VARCHAR(16) DEFAULT NULL,
+    ai_playouts INT DEFAULT NULL,
+    ai_time_limit_ms INT DEFAULT NULL,
+    private BOOLEAN NOT NULL DEFAULT FALSE,
+    clock_base_ms INT DEFAULT NULL,
+    clock_increment_ms INT DEFAULT NULL,
+    clock_move_ms INT DEFAULT NULL,
+    clock_resumed_at TIMESTAMP(3) DEFAULT NULL,
+    rematch_of VARCHAR(36) DEFAULT NULL,
+    series_id VARCHAR(36) DEFAULT NULL,
+    rematch_offer ENUM('black', 'white') DEFAULT NULL,
+    black_player VARCHAR(64) DEFAULT NULL,
+    white_player VARCHAR(64) DEFAULT NULL,
+    black_rating DOUBLE DEFAULT NULL,
+    white_rating DOUBLE DEFAULT NULL,
+    played_at DATETIME DEFAULT NULL,
+    start_position CHAR(65) DEFAULT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_rematch_of (rematch_of),
+    INDEX idx_series (series_id)
 );
 
 CREATE TABLE IF NOT EXISTS moves (
@@ -43,7 +139,55 @@ CREATE TABLE IF NOT EXISTS moves (
     col TINYINT NOT NULL,
     `row` TINYINT NOT NULL,
     move_order INT NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    pass BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
     FOREIGN KEY (play_id) REFERENCES games(play_id),
     UNIQUE KEY uk_play_move (play_id, move_order)
 );
+
+CREATE TABLE IF NOT EXISTS reverted_moves (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    play_id VARCHAR(36) NOT NULL,
+    color ENUM('black', 'white') NOT NULL,
+    col TINYINT NOT NULL,
+    `row` TINYINT NOT NULL,
+    move_order INT NOT NULL,
+    pass BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP(3) NOT NULL,
+    requested_by ENUM('black', 'white') NOT NULL,
+    takeback INT NOT NULL DEFAULT 0,
+    reverted_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (play_id) REFERENCES games(play_id),
+    INDEX idx_play_move (play_id, move_order)
+);
+
+CREATE TABLE IF NOT EXISTS spectators (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    play_id VARCHAR(36) NOT NULL,
+    token VARCHAR(36) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (play_id) REFERENCES games(play_id),
+    UNIQUE KEY uk_token (token)
+);
+
+CREATE TABLE IF NOT EXISTS archived_games LIKE games;
+
+CREATE TABLE IF NOT EXISTS archived_moves LIKE moves;
+
+CREATE TABLE IF NOT EXISTS reference_games (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    source VARCHAR(64) NOT NULL,
+    source_index INT NOT NULL,
+    year SMALLINT NOT NULL,
+    tournament VARCHAR(26),
+    black_player VARCHAR(20),
+    white_player VARCHAR(20),
+    black_score TINYINT NOT NULL,
+    theoretical_score TINYINT NOT NULL,
+    transcript VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_source (source, source_index),
+    INDEX idx_transcript (transcript),
+    INDEX idx_black_player (black_player),
+    INDEX idx_white_player (white_player)
+);